@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/VMT1312/Chirpy/internal/database"
+)
+
+const (
+	defaultDeletionGrace     = 30 * 24 * time.Hour
+	accountDeletionBatchSize = 20
+)
+
+// deletionGraceFromEnv reads how long a deleted account can still be
+// recovered by logging in. ACCOUNT_DELETION_GRACE takes a Go duration such
+// as "720h".
+func deletionGraceFromEnv() (time.Duration, error) {
+	s := os.Getenv("ACCOUNT_DELETION_GRACE")
+	if s == "" {
+		return defaultDeletionGrace, nil
+	}
+
+	grace, err := time.ParseDuration(s)
+	if err != nil || grace < 0 {
+		return 0, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE %q", s)
+	}
+	return grace, nil
+}
+
+func (cfg *apiConfig) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	params := parameter{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	if err := auth.CheckPasswordHash(params.Password, dbUser.HashedPassword); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect password")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	deleteAfter, err := q.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+		GraceSeconds: int32(cfg.deletionGrace.Seconds()),
+		ID:           userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	if err := q.RevokeUserRefreshTokens(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	respondWithJson(w, http.StatusAccepted, AccountDeletion{
+		DeleteAfter: deleteAfter.Time,
+	})
+}
+
+// runAccountPurger removes accounts whose deletion grace period has passed.
+// Chirps, media, likes, follows and the rest cascade from the users row;
+// messages the account sent stay in their conversations without a sender.
+func (cfg *apiConfig) runAccountPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.purgeDueAccounts(context.Background())
+			if err != nil {
+				log.Printf("Failed to purge deleted accounts: %v", err)
+				break
+			}
+			if n < accountDeletionBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// purgeDueAccounts deletes one batch of accounts and reports how many it
+// removed. As with the trash, blobs go only after the rows are committed.
+func (cfg *apiConfig) purgeDueAccounts(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	ids, err := q.LockDueUserDeletions(ctx, accountDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var keys, exportKeys []string
+	for _, id := range ids {
+		media, err := q.GetMediaKeysByUser(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range media {
+			keys = append(keys, m.StorageKey, m.ThumbnailKey)
+		}
+		exports, err := q.GetExportKeysByUser(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range exports {
+			exportKeys = append(exportKeys, key.String)
+		}
+
+		// Other users' rechirps would otherwise survive as empty chirps
+		// pointing at nothing.
+		if err := q.DeleteRechirpsOfUser(ctx, id); err != nil {
+			return 0, err
+		}
+		if err := q.DeleteUser(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := cfg.blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+	for _, key := range exportKeys {
+		if err := cfg.exportBlobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete export %s: %v", key, err)
+		}
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// targetUserID parses the {userID} path value for block, mute and list
+// member endpoints, rejecting the caller's own ID and unknown users.
+func (cfg *apiConfig) targetUserID(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return uuid.Nil, false
+	}
+	if targetID == userID {
+		respondWithError(w, http.StatusBadRequest, "You cannot target yourself")
+		return uuid.Nil, false
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), targetID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return uuid.Nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return uuid.Nil, false
+	}
+
+	return targetID, true
+}
+
+func (cfg *apiConfig) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	blockedID, ok := cfg.targetUserID(w, r, userID)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to block user")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	// Taken in the same order as followUserHandler, so a concurrent follow
+	// either commits before the follows below are deleted or sees the block.
+	err = q.LockUserPair(r.Context(), database.LockUserPairParams{
+		UserA: userID,
+		UserB: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to block user")
+		return
+	}
+
+	err = q.BlockUser(r.Context(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to block user")
+		return
+	}
+
+	// A block severs the follow relationship in both directions.
+	err = q.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		UserA: userID,
+		UserB: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to remove follows")
+		return
+	}
+
+	for _, pair := range [][2]uuid.UUID{{userID, blockedID}, {blockedID, userID}} {
+		err = q.RemoveAuthorFromTimeline(r.Context(), database.RemoveAuthorFromTimelineParams{
+			UserID:   pair[0],
+			AuthorID: pair[1],
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update timeline")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to block user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	_, err = cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to unblock user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getBlockedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetBlockedUsers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve blocked users")
+		return
+	}
+
+	relations := make([]UserRelation, len(rows))
+	for i, row := range rows {
+		relations[i] = UserRelation{
+			UserID:    row.BlockedID.String(),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, relations)
+}
+
+func (cfg *apiConfig) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	mutedID, ok := cfg.targetUserID(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to mute user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	_, err = cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to unmute user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getMutedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetMutedUsers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve muted users")
+		return
+	}
+
+	relations := make([]UserRelation, len(rows))
+	for i, row := range rows {
+		relations[i] = UserRelation{
+			UserID:    row.MutedID.String(),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, relations)
+}
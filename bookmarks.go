@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) bookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpBookmark(w, r, true)
+}
+
+func (cfg *apiConfig) unbookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpBookmark(w, r, false)
+}
+
+func (cfg *apiConfig) setChirpBookmark(w http.ResponseWriter, r *http.Request, bookmark bool) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	if bookmark {
+		_, err = cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+			ID:       chirpID,
+			ViewerID: userID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "Chirp not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+
+		err = cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+	} else {
+		// Removing a bookmark works even if the chirp has since become
+		// invisible to the caller.
+		err = cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update bookmark")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbChirps, err := cfg.db.GetBookmarks(r.Context(), database.GetBookmarksParams{
+		UserID:          userID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve bookmarks")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve bookmarks")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, ChirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor(dbChirps, pageSize),
+	})
+}
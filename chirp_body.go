@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxChirpLength = 140
+
+var errChirpTooLong = errors.New("Body exceeds 140 characters")
+
+// cleanChirpBody enforces the length limit and censors banned words. Every
+// path that stores a chirp body goes through it.
+func cleanChirpBody(body string) (string, error) {
+	if utf8.RuneCountInString(body) > maxChirpLength {
+		return "", errChirpTooLong
+	}
+
+	words := strings.Split(body, " ")
+	for i, word := range words {
+		if _, ok := bannedWords[strings.ToLower(word)]; ok {
+			words[i] = "****"
+		}
+	}
+
+	return strings.Join(words, " "), nil
+}
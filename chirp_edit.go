@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) updateChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	params := parameter{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	body, err := cleanChirpBody(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+	if dbChirp.UserID != userID {
+		visible, err := cfg.canSeeDBChirp(r.Context(), userID, dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusForbidden, "You do not have permission to edit this chirp")
+		return
+	}
+	if dbChirp.Status != "published" {
+		respondWithError(w, http.StatusBadRequest, "Drafts are edited with PUT /api/drafts/{draftID}")
+		return
+	}
+	if dbChirp.Kind == "rechirp" {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update chirp")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	err = q.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID: dbChirp.ID,
+		Body:    dbChirp.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to save chirp revision")
+		return
+	}
+
+	dbChirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		Body: body,
+		ID:   dbChirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update chirp")
+		return
+	}
+
+	if err := saveChirpTags(r.Context(), q, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update chirp tags")
+		return
+	}
+
+	mentioned, err := saveChirpMentions(r.Context(), q, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update chirp mentions")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update chirp")
+		return
+	}
+
+	cfg.publishStreamEvent(r.Context(), stream.ChirpUpdated, dbChirp)
+
+	if err := cfg.notifyMentioned(r.Context(), dbChirp, mentioned); err != nil {
+		log.Printf("Failed to notify mentions of chirp %s: %v", dbChirp.ID, err)
+	}
+	if err := cfg.notifyCensored(r.Context(), dbChirp, params.Body); err != nil {
+		log.Printf("Failed to notify moderation of chirp %s: %v", dbChirp.ID, err)
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirp)
+}
+
+func (cfg *apiConfig) getChirpRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	_, err = cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: cfg.viewerID(r),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	dbRevisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve revisions")
+		return
+	}
+
+	revisions := make([]ChirpRevision, len(dbRevisions))
+	for i, dbRevision := range dbRevisions {
+		revisions[i] = ChirpRevision{
+			Body:      dbRevision.Body,
+			CreatedAt: dbRevision.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, revisions)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:         dbChirp.ID.String(),
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		UserID:     dbChirp.UserID.String(),
+		Kind:       dbChirp.Kind,
+		Visibility: dbChirp.Visibility,
+		Pinned:     dbChirp.PinnedAt.Valid,
+		Mentions:   []MentionEntity{},
+		Media:      []Media{},
+		LikeCount:  dbChirp.LikeCount,
+	}
+
+	if dbChirp.OriginalChirpID.Valid {
+		originalID := dbChirp.OriginalChirpID.UUID.String()
+		chirp.OriginalChirpID = &originalID
+	}
+	// Only drafts and scheduled chirps carry their status.
+	if dbChirp.Status != "published" {
+		chirp.Status = dbChirp.Status
+	}
+	if dbChirp.PublishAt.Valid {
+		chirp.PublishAt = &dbChirp.PublishAt.Time
+	}
+	if dbChirp.DeletedAt.Valid {
+		chirp.DeletedAt = &dbChirp.DeletedAt.Time
+	}
+	if dbChirp.ReplyToID.Valid {
+		replyToID := dbChirp.ReplyToID.UUID.String()
+		chirp.ReplyToID = &replyToID
+	}
+
+	return chirp
+}
+
+// buildChirps converts database chirps into API chirps annotated for the
+// viewer, embedding the original of every rechirp and quote. Pass uuid.Nil for
+// anonymous requests.
+func (cfg *apiConfig) buildChirps(ctx context.Context, viewerID uuid.UUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirps, err := cfg.annotateChirps(ctx, viewerID, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	var originalIDs []uuid.UUID
+	for _, dbChirp := range dbChirps {
+		if dbChirp.OriginalChirpID.Valid {
+			originalIDs = append(originalIDs, dbChirp.OriginalChirpID.UUID)
+		}
+	}
+
+	originals := map[uuid.UUID]*Chirp{}
+	if len(originalIDs) > 0 {
+		dbOriginals, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+			Ids:      originalIDs,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		embedded, err := cfg.annotateChirps(ctx, viewerID, dbOriginals)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range embedded {
+			originals[dbOriginals[i].ID] = &embedded[i]
+		}
+	}
+
+	for i, dbChirp := range dbChirps {
+		if dbChirp.Kind == "original" {
+			continue
+		}
+
+		// A quote outlives its original; the embed is replaced by a marker.
+		original, ok := originals[dbChirp.OriginalChirpID.UUID]
+		if !dbChirp.OriginalChirpID.Valid || !ok {
+			chirps[i].OriginalUnavailable = true
+			continue
+		}
+		chirps[i].Original = original
+	}
+
+	return chirps, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, viewerID uuid.UUID, dbChirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, viewerID, []database.Chirp{dbChirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+
+	return chirps[0], nil
+}
+
+func (cfg *apiConfig) annotateChirps(ctx context.Context, viewerID uuid.UUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirps := make([]Chirp, len(dbChirps))
+	ids := make([]uuid.UUID, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDB(dbChirp)
+		ids[i] = dbChirp.ID
+	}
+
+	if len(dbChirps) == 0 {
+		return chirps, nil
+	}
+
+	mentions, err := cfg.db.GetMentionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[uuid.UUID]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	for _, mention := range mentions {
+		i := index[mention.ChirpID]
+		chirps[i].Mentions = append(chirps[i].Mentions, MentionEntity{
+			UserID: mention.UserID.String(),
+			Handle: mention.Handle.String,
+			Start:  mention.StartOffset,
+			End:    mention.EndOffset,
+		})
+	}
+
+	attachments, err := cfg.db.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range attachments {
+		i := index[m.ChirpID]
+		chirps[i].Media = append(chirps[i].Media, mediaFromDB(
+			m.ID, m.ContentType, m.Width, m.Height, m.StorageKey, m.ThumbnailKey,
+		))
+	}
+
+	polls, err := cfg.loadPolls(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, poll := range polls {
+		chirps[index[id]].Poll = poll
+	}
+
+	if viewerID == uuid.Nil {
+		return chirps, nil
+	}
+
+	likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+
+	for i := range chirps {
+		likedByMe := liked[ids[i]]
+		chirps[i].LikedByMe = &likedByMe
+		bookmarkedByMe := bookmarked[ids[i]]
+		chirps[i].BookmarkedByMe = &bookmarkedByMe
+	}
+
+	return chirps, nil
+}
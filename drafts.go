@@ -0,0 +1,465 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const scheduledBatchSize = 50
+
+// draftSchedule turns the requested publish time into a draft status. A draft
+// without publish_at stays a draft until it is published explicitly.
+func draftSchedule(publishAt *time.Time) (string, sql.NullTime, error) {
+	if publishAt == nil {
+		return "draft", sql.NullTime{}, nil
+	}
+	if !publishAt.After(time.Now()) {
+		return "", sql.NullTime{}, errors.New("publish_at must be in the future")
+	}
+	return "scheduled", sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+}
+
+// publishDraft publishes a draft or scheduled chirp, running the length and
+// moderation checks on its body again first. q should be bound to the
+// transaction holding the chirp's row lock.
+func (cfg *apiConfig) publishDraft(ctx context.Context, q *database.Queries, draft database.Chirp) (database.Chirp, []uuid.UUID, error) {
+	body, err := cleanChirpBody(draft.Body)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	published, err := q.PublishChirp(ctx, database.PublishChirpParams{
+		Body: body,
+		ID:   draft.ID,
+	})
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	mentioned, err := indexChirp(ctx, q, published)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	err = recordWebhookEvent(ctx, q, published.UserID, webhookChirpCreated, chirpFromDB(published))
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	if err := cfg.federateChirp(ctx, q, published); err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	return published, mentioned, nil
+}
+
+func (cfg *apiConfig) draftID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID format")
+		return uuid.Nil, false
+	}
+	return draftID, true
+}
+
+func (cfg *apiConfig) createDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Drafts keep the body as written. It is censored when published, and
+	// comparing against the original tells the author whether it was.
+	if _, err := cleanChirpBody(params.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, publishAt, err := draftSchedule(params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(params.MediaIDs) > 0 {
+		if code, msg := cfg.validateChirpMedia(r, userID, params.MediaIDs); code != 0 {
+			respondWithError(w, code, msg)
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create draft")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	dbDraft, err := q.CreateDraft(r.Context(), database.CreateDraftParams{
+		Body:       params.Body,
+		UserID:     userID,
+		Status:     status,
+		PublishAt:  publishAt,
+		Visibility: visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create draft")
+		return
+	}
+
+	if len(params.MediaIDs) > 0 {
+		err = q.AttachChirpMedia(r.Context(), database.AttachChirpMediaParams{
+			ChirpID:  dbDraft.ID,
+			MediaIds: params.MediaIDs,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				respondWithError(w, http.StatusBadRequest, "Media not found or already attached")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to attach media")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create draft")
+		return
+	}
+
+	draft, err := cfg.buildChirp(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, draft)
+}
+
+func (cfg *apiConfig) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	dbDrafts, err := cfg.db.GetDraftsByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve drafts")
+		return
+	}
+
+	drafts, err := cfg.buildChirps(r.Context(), userID, dbDrafts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve drafts")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, drafts)
+}
+
+func (cfg *apiConfig) getDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, ok := cfg.draftID(w, r)
+	if !ok {
+		return
+	}
+
+	dbDraft, err := cfg.db.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve draft")
+		return
+	}
+
+	draft, err := cfg.buildChirp(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, draft)
+}
+
+func (cfg *apiConfig) updateDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, ok := cfg.draftID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Drafts keep the body as written. It is censored when published, and
+	// comparing against the original tells the author whether it was.
+	if _, err := cleanChirpBody(params.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, publishAt, err := draftSchedule(params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbDraft, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:       params.Body,
+		Status:     status,
+		PublishAt:  publishAt,
+		Visibility: visibility,
+		ID:         draftID,
+		UserID:     userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to update draft")
+		return
+	}
+
+	draft, err := cfg.buildChirp(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, draft)
+}
+
+func (cfg *apiConfig) deleteDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, ok := cfg.draftID(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete draft")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) publishDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, ok := cfg.draftID(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to publish draft")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	// Locking the row keeps the scheduler from publishing it at the same time.
+	dbDraft, err := q.GetDraftForUpdate(r.Context(), database.GetDraftForUpdateParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve draft")
+		return
+	}
+
+	dbChirp, mentioned, err := cfg.publishDraft(r.Context(), q, dbDraft)
+	if err != nil {
+		if errors.Is(err, errChirpTooLong) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to publish draft")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to publish draft")
+		return
+	}
+
+	if err := cfg.announceChirp(r.Context(), dbChirp, mentioned); err != nil {
+		log.Printf("Failed to announce chirp %s: %v", dbChirp.ID, err)
+	}
+	if err := cfg.notifyCensored(r.Context(), dbChirp, dbDraft.Body); err != nil {
+		log.Printf("Failed to notify moderation of chirp %s: %v", dbChirp.ID, err)
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirp)
+}
+
+// runScheduler publishes scheduled chirps once they are due. Several
+// instances can run it at once: each due chirp is claimed with
+// FOR UPDATE SKIP LOCKED and moved to published in the same transaction, so
+// exactly one instance publishes it.
+func (cfg *apiConfig) runScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.publishDueChirps(context.Background())
+			if err != nil {
+				log.Printf("Failed to publish scheduled chirps: %v", err)
+				break
+			}
+			if n < scheduledBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// publishDueChirps publishes one batch of due chirps and reports how many it
+// finished with. Chirps that no longer pass the length check go back to
+// being drafts and their authors are notified. Chirps that fail for any
+// other reason stay scheduled and are retried on the next tick.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	due, err := q.LockDueScheduledChirps(ctx, scheduledBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type publication struct {
+		chirp     database.Chirp
+		submitted string
+		mentioned []uuid.UUID
+	}
+	var published []publication
+	var rejected []database.Chirp
+
+	// Each chirp publishes under a savepoint, so one that fails is rolled
+	// back alone instead of taking the rest of the batch with it.
+	for _, draft := range due {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT publish_chirp"); err != nil {
+			return 0, err
+		}
+
+		dbChirp, mentioned, err := cfg.publishDraft(ctx, q, draft)
+		if err != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT publish_chirp"); err != nil {
+				return 0, err
+			}
+			if !errors.Is(err, errChirpTooLong) {
+				log.Printf("Failed to publish scheduled chirp %s: %v", draft.ID, err)
+				continue
+			}
+			if err := q.UnscheduleChirp(ctx, draft.ID); err != nil {
+				return 0, err
+			}
+			rejected = append(rejected, draft)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT publish_chirp"); err != nil {
+			return 0, err
+		}
+		published = append(published, publication{chirp: dbChirp, submitted: draft.Body, mentioned: mentioned})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range published {
+		if err := cfg.announceChirp(ctx, p.chirp, p.mentioned); err != nil {
+			log.Printf("Failed to announce chirp %s: %v", p.chirp.ID, err)
+		}
+		if err := cfg.notifyCensored(ctx, p.chirp, p.submitted); err != nil {
+			log.Printf("Failed to notify moderation of chirp %s: %v", p.chirp.ID, err)
+		}
+	}
+	for _, draft := range rejected {
+		if err := cfg.notify(ctx, draft.UserID, notificationModeration, uuid.Nil, draft.ID); err != nil {
+			log.Printf("Failed to notify moderation of chirp %s: %v", draft.ID, err)
+		}
+	}
+
+	// Chirps left scheduled are not counted, so a batch that keeps failing
+	// waits for the next tick instead of being retried straight away.
+	return len(published) + len(rejected), nil
+}
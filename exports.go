@@ -0,0 +1,395 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/VMT1312/Chirpy/internal/blobstore"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	exportRetention = 7 * 24 * time.Hour
+	exportLinkTTL   = 15 * time.Minute
+	// exportStaleAfter is how long a running export can go without finishing
+	// before another worker assumes it crashed and builds it again.
+	exportStaleAfter   = time.Hour
+	exportCleanupBatch = 100
+)
+
+type exportChirp struct {
+	Chirp
+	Revisions []ChirpRevision `json:"revisions"`
+}
+
+type exportChirpRef struct {
+	ChirpID   string    `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func exportDownloadPath(id uuid.UUID) string {
+	return "/api/me/export/" + id.String() + "/download"
+}
+
+func (cfg *apiConfig) exportFromDB(dbExport database.DataExport) Export {
+	export := Export{
+		ID:        dbExport.ID.String(),
+		Status:    dbExport.Status,
+		CreatedAt: dbExport.CreatedAt,
+	}
+	if dbExport.CompletedAt.Valid {
+		export.CompletedAt = &dbExport.CompletedAt.Time
+	}
+	if dbExport.Status == "ready" {
+		export.ExpiresAt = &dbExport.ExpiresAt.Time
+		path := exportDownloadPath(dbExport.ID)
+		export.DownloadURL = path + "?" + auth.SignURL(path, time.Now().Add(exportLinkTTL), cfg.JWTSecret)
+	}
+	return export
+}
+
+func (cfg *apiConfig) createExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	dbExport, err := cfg.db.CreateExport(r.Context(), userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "An export is already in progress")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to create export")
+		return
+	}
+
+	respondWithJson(w, http.StatusAccepted, cfg.exportFromDB(dbExport))
+}
+
+func (cfg *apiConfig) getExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid export ID format")
+		return
+	}
+
+	dbExport, err := cfg.db.GetExport(r.Context(), database.GetExportParams{
+		ID:     exportID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Export not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve export")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, cfg.exportFromDB(dbExport))
+}
+
+// downloadExportHandler serves an export archive. It takes no bearer token:
+// the signed link from getExportHandler is the credential, so it can be
+// opened directly in a browser.
+func (cfg *apiConfig) downloadExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid export ID format")
+		return
+	}
+
+	if err := auth.ValidateSignedURL(exportDownloadPath(exportID), r.URL.Query(), cfg.JWTSecret); err != nil {
+		if errors.Is(err, auth.ErrSignatureExpired) {
+			respondWithError(w, http.StatusForbidden, "Download link has expired")
+			return
+		}
+		respondWithError(w, http.StatusForbidden, "Invalid download link")
+		return
+	}
+
+	dbExport, err := cfg.db.GetExportByID(r.Context(), exportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Export not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve export")
+		return
+	}
+	if dbExport.Status != "ready" {
+		respondWithError(w, http.StatusNotFound, "Export not found")
+		return
+	}
+
+	body, info, err := cfg.exportBlobs.Get(r.Context(), dbExport.StorageKey.String)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Export not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve export")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.zip"`, dbExport.CreatedAt.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if info.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// runExporter builds queued exports one at a time and removes archives that
+// have outlived exportRetention.
+func (cfg *apiConfig) runExporter(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			built, err := cfg.buildNextExport(context.Background())
+			if err != nil {
+				log.Printf("Failed to claim export: %v", err)
+				break
+			}
+			if !built {
+				break
+			}
+		}
+		if err := cfg.expireExports(context.Background()); err != nil {
+			log.Printf("Failed to expire exports: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// buildNextExport claims one queued export and builds it, reporting whether
+// there was one to claim. A failed build marks the export failed instead of
+// returning an error, so one bad export does not stall the queue.
+func (cfg *apiConfig) buildNextExport(ctx context.Context) (bool, error) {
+	dbExport, err := cfg.db.ClaimExport(ctx, int32(exportStaleAfter.Seconds()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	archive, err := cfg.buildExportArchive(ctx, dbExport.UserID)
+	if err == nil {
+		key := dbExport.ID.String() + ".zip"
+		err = cfg.exportBlobs.Put(ctx, key, "application/zip", archive)
+		if err == nil {
+			err = cfg.db.CompleteExport(ctx, database.CompleteExportParams{
+				StorageKey:       sql.NullString{String: key, Valid: true},
+				SizeBytes:        sql.NullInt64{Int64: int64(len(archive)), Valid: true},
+				RetentionSeconds: int32(exportRetention.Seconds()),
+				ID:               dbExport.ID,
+			})
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to build export %s: %v", dbExport.ID, err)
+		if err := cfg.db.FailExport(ctx, dbExport.ID); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// buildExportArchive collects everything userID has put into Chirpy into a
+// ZIP archive. Chirps include drafts and the trash; media files sit under
+// media/ with the same names as in their /media/ URLs.
+func (cfg *apiConfig) buildExportArchive(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	dbUser, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbChirps, err := cfg.db.GetChirpsForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	annotated, err := cfg.annotateChirps(ctx, userID, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+	dbRevisions, err := cfg.db.GetRevisionsForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make(map[uuid.UUID][]ChirpRevision)
+	for _, rev := range dbRevisions {
+		revisions[rev.ChirpID] = append(revisions[rev.ChirpID], ChirpRevision{
+			Body:      rev.Body,
+			CreatedAt: rev.CreatedAt,
+		})
+	}
+	chirps := make([]exportChirp, len(annotated))
+	for i, chirp := range annotated {
+		chirps[i] = exportChirp{Chirp: chirp, Revisions: revisions[dbChirps[i].ID]}
+		if chirps[i].Revisions == nil {
+			chirps[i].Revisions = []ChirpRevision{}
+		}
+	}
+
+	dbLikes, err := cfg.db.GetLikesForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	likes := make([]exportChirpRef, len(dbLikes))
+	for i, like := range dbLikes {
+		likes[i] = exportChirpRef{ChirpID: like.ChirpID.String(), CreatedAt: like.CreatedAt}
+	}
+
+	dbBookmarks, err := cfg.db.GetBookmarksForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks := make([]exportChirpRef, len(dbBookmarks))
+	for i, bookmark := range dbBookmarks {
+		bookmarks[i] = exportChirpRef{ChirpID: bookmark.ChirpID.String(), CreatedAt: bookmark.CreatedAt}
+	}
+
+	dbFollowing, err := cfg.db.GetFollowing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	following := make([]Follow, len(dbFollowing))
+	for i, row := range dbFollowing {
+		following[i] = Follow{UserID: row.FolloweeID.String(), FollowedAt: row.CreatedAt}
+	}
+
+	dbFollowers, err := cfg.db.GetFollowers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	followers := make([]Follow, len(dbFollowers))
+	for i, row := range dbFollowers {
+		followers[i] = Follow{UserID: row.FollowerID.String(), FollowedAt: row.CreatedAt}
+	}
+
+	dbMedia, err := cfg.db.GetMediaForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	media := make([]Media, len(dbMedia))
+	for i, m := range dbMedia {
+		media[i] = mediaFromDB(m.ID, m.ContentType, m.Width, m.Height, m.StorageKey, m.ThumbnailKey)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", userFromDB(dbUser)},
+		{"chirps.json", chirps},
+		{"likes.json", likes},
+		{"bookmarks.json", bookmarks},
+		{"following.json", following},
+		{"followers.json", followers},
+		{"media.json", media},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range dbMedia {
+		if err := copyBlobToZip(ctx, cfg.blobs, zw, m.StorageKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func copyBlobToZip(ctx context.Context, blobs blobstore.BlobStore, zw *zip.Writer, key string) error {
+	body, _, err := blobs.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Images are already compressed, so they are stored as-is.
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "media/" + key, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, body)
+	return err
+}
+
+// expireExports deletes archives past their retention. The rows stay, marked
+// expired, so the owner can see what happened to them.
+func (cfg *apiConfig) expireExports(ctx context.Context) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	expired, err := q.LockExpiredExports(ctx, exportCleanupBatch)
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(expired))
+	for i, e := range expired {
+		ids[i] = e.ID
+	}
+	if err := q.ExpireExports(ctx, ids); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, e := range expired {
+		if err := cfg.exportBlobs.Delete(ctx, e.StorageKey.String); err != nil {
+			log.Printf("Failed to delete blob %s: %v", e.StorageKey.String, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/activitypub"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	outboxSize          = 20
+	maxInboxSize        = 1 << 20
+	remoteActorTTL      = 24 * time.Hour
+	deliveryBatchSize   = 20
+	deliveryLease       = 5 * time.Minute
+	deliveryMaxAttempts = 8
+	deliveryBaseDelay   = 30 * time.Second
+	deliveryMaxDelay    = 6 * time.Hour
+)
+
+func (cfg *apiConfig) actorURL(userID uuid.UUID) string {
+	return cfg.baseURL + "/ap/users/" + userID.String()
+}
+
+func (cfg *apiConfig) noteURL(chirpID uuid.UUID) string {
+	return cfg.baseURL + "/ap/chirps/" + chirpID.String()
+}
+
+// federates reports whether a chirp is sent to remote followers. Private
+// chirps stay local and rechirps have no Note of their own.
+func federates(dbChirp database.Chirp) bool {
+	return dbChirp.Status == "published" && dbChirp.Kind != "rechirp" && dbChirp.Visibility != visibilityPrivate
+}
+
+// ensureActorKeys returns dbUser with an actor key pair, generating one on
+// first use. When two requests race, the key stored first wins.
+func (cfg *apiConfig) ensureActorKeys(ctx context.Context, dbUser database.User) (database.User, error) {
+	if dbUser.ApPrivateKey.Valid {
+		return dbUser, nil
+	}
+
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.User{}, err
+	}
+	n, err := cfg.db.SetUserActorKeys(ctx, database.SetUserActorKeysParams{
+		ID:           dbUser.ID,
+		ApPrivateKey: sql.NullString{String: privatePEM, Valid: true},
+		ApPublicKey:  sql.NullString{String: publicPEM, Valid: true},
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	if n == 0 {
+		return cfg.db.GetUserByID(ctx, dbUser.ID)
+	}
+
+	dbUser.ApPrivateKey = sql.NullString{String: privatePEM, Valid: true}
+	dbUser.ApPublicKey = sql.NullString{String: publicPEM, Valid: true}
+	return dbUser, nil
+}
+
+// federatedUser loads a local user that can be seen over ActivityPub: one
+// with a handle and no pending deletion.
+func (cfg *apiConfig) federatedUser(r *http.Request) (database.User, error) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	if !dbUser.Handle.Valid || dbUser.DeleteAfter.Valid {
+		return database.User{}, sql.ErrNoRows
+	}
+	return dbUser, nil
+}
+
+func respondWithActivity(w http.ResponseWriter, code int, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) noteFromDB(dbChirp database.Chirp) activitypub.Note {
+	actor := cfg.actorURL(dbChirp.UserID)
+	followers := actor + "/followers"
+
+	note := activitypub.Note{
+		ID:           cfg.noteURL(dbChirp.ID),
+		Type:         "Note",
+		AttributedTo: actor,
+		Content:      "<p>" + strings.ReplaceAll(html.EscapeString(dbChirp.Body), "\n", "<br>") + "</p>",
+		Published:    dbChirp.CreatedAt.UTC(),
+		URL:          cfg.baseURL + "/api/chirps/" + dbChirp.ID.String(),
+	}
+	if dbChirp.ReplyToID.Valid {
+		note.InReplyTo = cfg.noteURL(dbChirp.ReplyToID.UUID)
+	}
+
+	switch dbChirp.Visibility {
+	case visibilityPublic:
+		note.To = []string{activitypub.Public}
+		note.Cc = []string{followers}
+	case visibilityUnlisted:
+		note.To = []string{followers}
+		note.Cc = []string{activitypub.Public}
+	default:
+		note.To = []string{followers}
+	}
+	return note
+}
+
+func (cfg *apiConfig) createActivity(dbChirp database.Chirp) (activitypub.Activity, error) {
+	note := cfg.noteFromDB(dbChirp)
+	activity, err := activitypub.NewActivity(note.ID+"/activity", "Create", note.AttributedTo, note)
+	if err != nil {
+		return activitypub.Activity{}, err
+	}
+	activity.Published = &note.Published
+	activity.To = note.To
+	activity.Cc = note.Cc
+	return activity, nil
+}
+
+// federateChirp queues a Create activity for every remote follower inbox of
+// the chirp's author. It runs in the transaction that publishes the chirp.
+func (cfg *apiConfig) federateChirp(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	if !federates(dbChirp) {
+		return nil
+	}
+
+	activity, err := cfg.createActivity(dbChirp)
+	if err != nil {
+		return err
+	}
+	return enqueueFollowerDeliveries(ctx, q, dbChirp.UserID, activity)
+}
+
+// federateChirpDeletion queues a Delete activity for a chirp that was sent
+// to remote followers when it was published. It runs in the transaction
+// that deletes the chirp.
+func (cfg *apiConfig) federateChirpDeletion(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	if !federates(dbChirp) {
+		return nil
+	}
+
+	note := cfg.noteFromDB(dbChirp)
+	activity, err := activitypub.NewActivity(note.ID+"#delete", "Delete", note.AttributedTo, activitypub.Tombstone{
+		ID:   note.ID,
+		Type: "Tombstone",
+	})
+	if err != nil {
+		return err
+	}
+	activity.To = note.To
+	activity.Cc = note.Cc
+	return enqueueFollowerDeliveries(ctx, q, dbChirp.UserID, activity)
+}
+
+func enqueueFollowerDeliveries(ctx context.Context, q *database.Queries, userID uuid.UUID, activity activitypub.Activity) error {
+	activity.Context = activitypub.Context
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	return q.EnqueueFollowerDeliveries(ctx, database.EnqueueFollowerDeliveriesParams{
+		UserID:   userID,
+		Activity: string(data),
+	})
+}
+
+func (cfg *apiConfig) webfingerHandler(w http.ResponseWriter, r *http.Request) {
+	handle, host, err := activitypub.ParseAcct(r.URL.Query().Get("resource"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	base, err := url.Parse(cfg.baseURL)
+	if err != nil || host != strings.ToLower(base.Host) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	dbProfile, err := cfg.db.GetPublicProfileByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+	active, err := cfg.db.IsActiveUser(r.Context(), dbProfile.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+	if !active {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	actor := cfg.actorURL(dbProfile.ID)
+	profileURL := cfg.baseURL + "/api/users/" + dbProfile.Handle.String
+
+	data, err := json.Marshal(activitypub.JRD{
+		Subject: "acct:" + dbProfile.Handle.String + "@" + base.Host,
+		Aliases: []string{actor, profileURL},
+		Links: []activitypub.Link{
+			{Rel: "self", Type: activitypub.ContentType, Href: actor},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "application/json", Href: profileURL},
+		},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	w.Header().Set("Content-Type", activitypub.JRDContentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) getActorHandler(w http.ResponseWriter, r *http.Request) {
+	dbUser, err := cfg.federatedUser(r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	dbUser, err = cfg.ensureActorKeys(r.Context(), dbUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load actor key")
+		return
+	}
+
+	actor := cfg.actorURL(dbUser.ID)
+	respondWithActivity(w, http.StatusOK, activitypub.Actor{
+		Context:           activitypub.Context,
+		ID:                actor,
+		Type:              "Person",
+		PreferredUsername: dbUser.Handle.String,
+		Name:              dbUser.DisplayName,
+		Summary:           html.EscapeString(dbUser.Bio),
+		URL:               cfg.baseURL + "/api/users/" + dbUser.Handle.String,
+		Inbox:             actor + "/inbox",
+		Outbox:            actor + "/outbox",
+		Followers:         actor + "/followers",
+		PublicKey: activitypub.PublicKey{
+			ID:           actor + "#main-key",
+			Owner:        actor,
+			PublicKeyPem: dbUser.ApPublicKey.String,
+		},
+	})
+}
+
+func (cfg *apiConfig) getOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	dbUser, err := cfg.federatedUser(r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByUserID(r.Context(), database.GetChirpsByUserIDParams{
+		UserID:   dbUser.ID,
+		ViewerID: uuid.Nil,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	var federated []database.Chirp
+	for _, dbChirp := range dbChirps {
+		if federates(dbChirp) {
+			federated = append(federated, dbChirp)
+		}
+	}
+	sort.SliceStable(federated, func(i, j int) bool {
+		return federated[i].CreatedAt.After(federated[j].CreatedAt)
+	})
+
+	outbox := activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         cfg.actorURL(dbUser.ID) + "/outbox",
+		Type:       "OrderedCollection",
+		TotalItems: int64(len(federated)),
+	}
+	if len(federated) > outboxSize {
+		federated = federated[:outboxSize]
+	}
+	for _, dbChirp := range federated {
+		activity, err := cfg.createActivity(dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to build outbox")
+			return
+		}
+		outbox.OrderedItems = append(outbox.OrderedItems, activity)
+	}
+
+	respondWithActivity(w, http.StatusOK, outbox)
+}
+
+func (cfg *apiConfig) getFollowersCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	dbUser, err := cfg.federatedUser(r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	count, err := cfg.db.CountRemoteFollowers(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to count followers")
+		return
+	}
+
+	respondWithActivity(w, http.StatusOK, activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         cfg.actorURL(dbUser.ID) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: count,
+	})
+}
+
+func (cfg *apiConfig) getNoteHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID")
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: uuid.Nil,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+	if !federates(dbChirp) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	note := cfg.noteFromDB(dbChirp)
+	note.Context = activitypub.Context
+	respondWithActivity(w, http.StatusOK, note)
+}
+
+// remoteActorKey resolves the key that signed an inbox request, from the
+// cache when it is fresh and from the sender's actor document otherwise.
+// The owning actor's URL is stored in actorURL.
+func (cfg *apiConfig) remoteActorKey(ctx context.Context, actorURL *string) func(string) (*rsa.PublicKey, error) {
+	return func(keyID string) (*rsa.PublicKey, error) {
+		dbActor, err := cfg.db.GetRemoteActorByKeyID(ctx, database.GetRemoteActorByKeyIDParams{
+			KeyID:         keyID,
+			MaxAgeSeconds: int32(remoteActorTTL.Seconds()),
+		})
+		if err == nil {
+			*actorURL = dbActor.ActorUrl
+			return activitypub.ParsePublicKey(dbActor.PublicKeyPem)
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		owner, _, _ := strings.Cut(keyID, "#")
+		actor, err := cfg.apClient.FetchActor(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		if actor.PublicKey.ID != keyID || !sameHost(keyID, actor.ID) {
+			return nil, errors.New("key does not belong to the fetched actor")
+		}
+		key, err := activitypub.ParsePublicKey(actor.PublicKey.PublicKeyPem)
+		if err != nil {
+			return nil, err
+		}
+
+		var sharedInbox sql.NullString
+		if actor.Endpoints != nil && actor.Endpoints.SharedInbox != "" {
+			sharedInbox = sql.NullString{String: actor.Endpoints.SharedInbox, Valid: true}
+		}
+		err = cfg.db.UpsertRemoteActor(ctx, database.UpsertRemoteActorParams{
+			ActorUrl:       actor.ID,
+			InboxUrl:       actor.Inbox,
+			SharedInboxUrl: sharedInbox,
+			KeyID:          keyID,
+			PublicKeyPem:   actor.PublicKey.PublicKeyPem,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		*actorURL = actor.ID
+		return key, nil
+	}
+}
+
+// sameHost reports whether two URLs point at the same host.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host != "" && ua.Host == ub.Host
+}
+
+func (cfg *apiConfig) inboxHandler(w http.ResponseWriter, r *http.Request) {
+	dbUser, err := cfg.federatedUser(r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboxSize))
+	if err != nil {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Activity is too large")
+		return
+	}
+
+	activity := activitypub.Activity{}
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Type == "" || activity.Actor == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid activity")
+		return
+	}
+
+	var signer string
+	if _, err := activitypub.VerifyRequest(r, body, cfg.remoteActorKey(r.Context(), &signer)); err != nil {
+		// A deleted account can no longer serve its key, so its Delete
+		// cannot be verified. Accept it without acting on it.
+		if activity.Type == "Delete" && activity.ObjectID() == activity.Actor {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "Invalid signature: "+err.Error())
+		return
+	}
+	if signer != activity.Actor {
+		respondWithError(w, http.StatusUnauthorized, "Activity actor does not match the signature")
+		return
+	}
+
+	actor := cfg.actorURL(dbUser.ID)
+	switch activity.Type {
+	case "Follow":
+		if activity.ObjectID() != actor {
+			respondWithError(w, http.StatusBadRequest, "Follow is not addressed to this user")
+			return
+		}
+		if err := cfg.acceptRemoteFollow(r.Context(), dbUser.ID, activity); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to accept follow")
+			return
+		}
+
+	case "Undo":
+		inner, err := activity.InnerActivity()
+		if err == nil && inner.Type == "Follow" && inner.Actor == activity.Actor {
+			err = cfg.db.RemoveRemoteFollower(r.Context(), database.RemoveRemoteFollowerParams{
+				UserID:   dbUser.ID,
+				ActorUrl: activity.Actor,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to remove follower")
+				return
+			}
+		}
+
+	case "Delete":
+		if activity.ObjectID() == activity.Actor {
+			if err := cfg.db.DeleteRemoteActor(r.Context(), activity.Actor); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to delete actor")
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// acceptRemoteFollow records a remote follower and queues the Accept in the
+// same transaction.
+func (cfg *apiConfig) acceptRemoteFollow(ctx context.Context, userID uuid.UUID, follow activitypub.Activity) error {
+	dbActor, err := cfg.db.GetRemoteActor(ctx, follow.Actor)
+	if err != nil {
+		return err
+	}
+
+	actor := cfg.actorURL(userID)
+	accept, err := activitypub.NewActivity(actor+"#accepts/"+uuid.NewString(), "Accept", actor, follow)
+	if err != nil {
+		return err
+	}
+	accept.Context = activitypub.Context
+	data, err := json.Marshal(accept)
+	if err != nil {
+		return err
+	}
+
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	err = q.AddRemoteFollower(ctx, database.AddRemoteFollowerParams{
+		UserID:   userID,
+		ActorUrl: follow.Actor,
+	})
+	if err != nil {
+		return err
+	}
+	err = q.EnqueueDelivery(ctx, database.EnqueueDeliveryParams{
+		UserID:   userID,
+		InboxUrl: dbActor.InboxUrl,
+		Activity: string(data),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (cfg *apiConfig) runDeliveryQueue(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.deliverDue(context.Background())
+			if err != nil {
+				log.Printf("Failed to deliver activities: %v", err)
+				break
+			}
+			if n < deliveryBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// deliverDue claims one batch of due deliveries and sends them. Claiming
+// leases each row for deliveryLease, so a delivery is retried even if this
+// process dies before recording the outcome.
+func (cfg *apiConfig) deliverDue(ctx context.Context) (int, error) {
+	deliveries, err := cfg.db.ClaimDueDeliveries(ctx, database.ClaimDueDeliveriesParams{
+		LeaseSeconds:  int32(deliveryLease.Seconds()),
+		MaxDeliveries: deliveryBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make(map[uuid.UUID]*rsa.PrivateKey)
+	for _, d := range deliveries {
+		key, ok := keys[d.UserID]
+		if !ok {
+			key, err = cfg.deliveryKey(ctx, d.UserID)
+			if err != nil {
+				return 0, err
+			}
+			keys[d.UserID] = key
+		}
+
+		err := cfg.apClient.Deliver(ctx, d.InboxUrl, []byte(d.Activity), cfg.actorURL(d.UserID)+"#main-key", key)
+		if err := cfg.recordDelivery(ctx, d, err); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (cfg *apiConfig) deliveryKey(ctx context.Context, userID uuid.UUID) (*rsa.PrivateKey, error) {
+	dbUser, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbUser, err = cfg.ensureActorKeys(ctx, dbUser)
+	if err != nil {
+		return nil, err
+	}
+	return activitypub.ParsePrivateKey(dbUser.ApPrivateKey.String)
+}
+
+// recordDelivery removes a delivered activity, or schedules a retry with
+// exponential backoff. Activities that were rejected outright or ran out of
+// attempts are kept as failed for inspection.
+func (cfg *apiConfig) recordDelivery(ctx context.Context, d database.ApDelivery, deliveryErr error) error {
+	if deliveryErr == nil {
+		return cfg.db.CompleteDelivery(ctx, d.ID)
+	}
+
+	if activitypub.IsPermanent(deliveryErr) || d.Attempts >= deliveryMaxAttempts {
+		log.Printf("Giving up on delivery %s to %s: %v", d.ID, d.InboxUrl, deliveryErr)
+		return cfg.db.FailDelivery(ctx, database.FailDeliveryParams{
+			ID:        d.ID,
+			LastError: sql.NullString{String: deliveryErr.Error(), Valid: true},
+		})
+	}
+
+	delay := deliveryBaseDelay << (d.Attempts - 1)
+	if delay > deliveryMaxDelay {
+		delay = deliveryMaxDelay
+	}
+	return cfg.db.RetryDelivery(ctx, database.RetryDeliveryParams{
+		DelaySeconds: int32(delay.Seconds()),
+		LastError:    sql.NullString{String: deliveryErr.Error(), Valid: true},
+		ID:           d.ID,
+	})
+}
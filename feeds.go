@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VMT1312/Chirpy/internal/chirptext"
+	"github.com/VMT1312/Chirpy/internal/cursor"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	feedSize        = 50
+	feedTitleLength = 60
+	feedFormatAtom  = "atom"
+	feedFormatRSS   = "rss"
+)
+
+// feed is a format-neutral list of public chirps, newest first.
+type feed struct {
+	title       string
+	description string
+	link        string
+	selfURL     string
+	updated     time.Time
+	entries     []feedEntry
+}
+
+type feedEntry struct {
+	id        uuid.UUID
+	link      string
+	title     string
+	body      string
+	author    string
+	authorURL string
+	published time.Time
+	updated   time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    atomAuthor  `xml:"author"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Author      string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// splitFeedName splits "name.atom" or "name.rss" from a feed URL.
+func splitFeedName(s string) (string, string, bool) {
+	for _, format := range []string{feedFormatAtom, feedFormatRSS} {
+		if name, ok := strings.CutSuffix(s, "."+format); ok && name != "" {
+			return name, format, true
+		}
+	}
+	return "", "", false
+}
+
+func feedEntryTitle(body string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(body) <= feedTitleLength {
+		return body
+	}
+	runes := []rune(body)
+	return string(runes[:feedTitleLength-1]) + "…"
+}
+
+// buildFeed keeps the newest public, non-rechirp chirps of dbChirps. The tag
+// feed reads the same query as the JSON endpoint, as an anonymous viewer, so
+// unlisted chirps are dropped here.
+func (cfg *apiConfig) buildFeed(ctx context.Context, f feed, dbChirps []database.Chirp) (feed, error) {
+	var public []database.Chirp
+	for _, dbChirp := range dbChirps {
+		if dbChirp.Visibility == visibilityPublic && dbChirp.Kind != "rechirp" {
+			public = append(public, dbChirp)
+		}
+	}
+	sort.SliceStable(public, func(i, j int) bool {
+		return public[i].CreatedAt.After(public[j].CreatedAt)
+	})
+	if len(public) > feedSize {
+		public = public[:feedSize]
+	}
+
+	authorIDs := make([]uuid.UUID, 0, len(public))
+	for _, dbChirp := range public {
+		authorIDs = append(authorIDs, dbChirp.UserID)
+	}
+	handles := make(map[uuid.UUID]string, len(authorIDs))
+	if len(authorIDs) > 0 {
+		rows, err := cfg.db.GetHandlesByIDs(ctx, authorIDs)
+		if err != nil {
+			return feed{}, err
+		}
+		for _, row := range rows {
+			if row.Handle.Valid {
+				handles[row.ID] = row.Handle.String
+			}
+		}
+	}
+
+	f.updated = time.Unix(0, 0).UTC()
+	for _, dbChirp := range public {
+		entry := feedEntry{
+			id:        dbChirp.ID,
+			link:      cfg.baseURL + "/api/chirps/" + dbChirp.ID.String(),
+			title:     feedEntryTitle(dbChirp.Body),
+			body:      dbChirp.Body,
+			author:    "Chirpy user",
+			published: dbChirp.CreatedAt.UTC(),
+			updated:   dbChirp.UpdatedAt.UTC(),
+		}
+		if handle, ok := handles[dbChirp.UserID]; ok {
+			entry.author = "@" + handle
+			entry.authorURL = cfg.baseURL + "/api/users/" + handle
+		}
+		if entry.updated.After(f.updated) {
+			f.updated = entry.updated
+		}
+		f.entries = append(f.entries, entry)
+	}
+
+	return f, nil
+}
+
+func (f feed) atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:      f.selfURL,
+		Title:   f.title,
+		Updated: f.updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.selfURL},
+			{Rel: "alternate", Href: f.link},
+		},
+	}
+	for _, e := range f.entries {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        "urn:uuid:" + e.id.String(),
+			Title:     e.title,
+			Published: e.published.Format(time.RFC3339),
+			Updated:   e.updated.Format(time.RFC3339),
+			Author:    atomAuthor{Name: e.author, URI: e.authorURL},
+			Link:      atomLink{Rel: "alternate", Href: e.link},
+			Content:   atomContent{Type: "text", Body: e.body},
+		})
+	}
+	return marshalFeed(doc)
+}
+
+func (f feed) rss() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.title,
+			Link:        f.link,
+			Description: f.description,
+		},
+	}
+	if len(f.entries) > 0 {
+		doc.Channel.LastBuildDate = f.updated.Format(time.RFC1123Z)
+	}
+	for _, e := range f.entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.title,
+			Link:        e.link,
+			Description: e.body,
+			Author:      e.author,
+			GUID:        rssGUID{Value: e.id.String()},
+			PubDate:     e.published.Format(time.RFC1123Z),
+		})
+	}
+	return marshalFeed(doc)
+}
+
+func marshalFeed(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// serveFeed writes f with an ETag over its exact bytes, which
+// http.ServeContent uses to answer If-None-Match with 304 Not Modified. There
+// is no Last-Modified: trashing or restoring a chirp changes the feed without
+// changing its newest timestamp.
+func serveFeed(w http.ResponseWriter, r *http.Request, f feed, format string) {
+	var body []byte
+	var err error
+	if format == feedFormatAtom {
+		body, err = f.atom()
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	} else {
+		body, err = f.rss()
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to build feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+func (cfg *apiConfig) getGlobalFeedHandler(w http.ResponseWriter, r *http.Request) {
+	name, format, ok := splitFeedName(r.PathValue("feed"))
+	if !ok || name != "chirps" {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+
+	dbChirps, err := cfg.db.GetFeedChirps(r.Context(), database.GetFeedChirpsParams{
+		PageSize: feedSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	f, err := cfg.buildFeed(r.Context(), feed{
+		title:       "Chirpy",
+		description: "The latest public chirps",
+		link:        cfg.baseURL + "/api/chirps",
+		selfURL:     cfg.baseURL + r.URL.Path,
+	}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	serveFeed(w, r, f, format)
+}
+
+func (cfg *apiConfig) getUserFeedHandler(w http.ResponseWriter, r *http.Request) {
+	handle, format, ok := splitFeedName(r.PathValue("feed"))
+	if !ok || !chirptext.ValidHandle(handle) {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+
+	dbProfile, err := cfg.db.GetPublicProfileByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	dbChirps, err := cfg.db.GetFeedChirps(r.Context(), database.GetFeedChirpsParams{
+		UserID:   uuid.NullUUID{UUID: dbProfile.ID, Valid: true},
+		PageSize: feedSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	f, err := cfg.buildFeed(r.Context(), feed{
+		title:       "@" + dbProfile.Handle.String + " on Chirpy",
+		description: "Public chirps by @" + dbProfile.Handle.String,
+		link:        cfg.baseURL + "/api/users/" + dbProfile.Handle.String,
+		selfURL:     cfg.baseURL + r.URL.Path,
+	}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	serveFeed(w, r, f, format)
+}
+
+func (cfg *apiConfig) getTagFeedHandler(w http.ResponseWriter, r *http.Request) {
+	name, format, ok := splitFeedName(r.PathValue("feed"))
+	tag := chirptext.NormalizeTag(name)
+	if !ok || tag == "" {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+
+	start := cursor.Start()
+	dbChirps, err := cfg.db.GetChirpsByTag(r.Context(), database.GetChirpsByTagParams{
+		Tag:             tag,
+		BeforeCreatedAt: start.CreatedAt,
+		BeforeID:        start.ID,
+		ViewerID:        uuid.Nil,
+		PageSize:        feedSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	f, err := cfg.buildFeed(r.Context(), feed{
+		title:       "#" + tag + " on Chirpy",
+		description: "Public chirps tagged #" + tag,
+		link:        cfg.baseURL + "/api/tags/" + tag + "/chirps",
+		selfURL:     cfg.baseURL + r.URL.Path,
+	}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	serveFeed(w, r, f, format)
+}
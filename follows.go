@@ -0,0 +1,192 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "You cannot follow yourself")
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), followeeID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	// The follow and the timeline backfill commit together, so a failed
+	// backfill can be retried instead of leaving a follow without history.
+	// The pair lock orders this request against a block between the two
+	// users, so a follow can never outlive the block that removed it.
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to follow user")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	err = q.LockUserPair(r.Context(), database.LockUserPairParams{
+		UserA: userID,
+		UserB: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to follow user")
+		return
+	}
+
+	blocked, err := q.IsBlockedEitherWay(r.Context(), database.IsBlockedEitherWayParams{
+		UserA: userID,
+		UserB: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check blocks")
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You cannot follow this user")
+		return
+	}
+
+	followed, err := q.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to follow user")
+		return
+	}
+
+	if followed > 0 {
+		err = q.BackfillTimeline(r.Context(), database.BackfillTimelineParams{
+			FollowerID: userID,
+			FolloweeID: followeeID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update timeline")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to follow user")
+		return
+	}
+
+	if followed > 0 {
+		if err := cfg.notify(r.Context(), followeeID, notificationFollow, userID, uuid.Nil); err != nil {
+			log.Printf("Failed to notify follow of user %s: %v", followeeID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	unfollowed, err := cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to unfollow user")
+		return
+	}
+
+	if unfollowed > 0 {
+		err = cfg.db.RemoveAuthorFromTimeline(r.Context(), database.RemoveAuthorFromTimelineParams{
+			UserID:   userID,
+			AuthorID: followeeID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update timeline")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	rows, err := cfg.db.GetFollowers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve followers")
+		return
+	}
+
+	list := FollowList{
+		Count: len(rows),
+		Users: make([]Follow, len(rows)),
+	}
+	for i, row := range rows {
+		list.Users[i] = Follow{
+			UserID:     row.FollowerID.String(),
+			FollowedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, list)
+}
+
+func (cfg *apiConfig) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	rows, err := cfg.db.GetFollowing(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve followed users")
+		return
+	}
+
+	list := FollowList{
+		Count: len(rows),
+		Users: make([]Follow, len(rows)),
+	}
+	for i, row := range rows {
+		list.Users[i] = Follow{
+			UserID:     row.FolloweeID.String(),
+			FollowedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, list)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotencyKeyHeader  = "Idempotency-Key"
+	maxIdempotencyKeySize = 255
+	idempotencyKeyTTL     = 24 * time.Hour
+	// A request that has held its key this long is assumed to have died
+	// with its server, and a retry may take the key over.
+	idempotencyLockTimeout = time.Minute
+	maxIdempotentBodySize  = maxMediaUploadSize + 64<<10
+)
+
+// responseRecorder buffers a handler's response so it can be stored before
+// it is sent.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+// middlewareIdempotency makes POST handlers safe to retry. The first response
+// to a request carrying an Idempotency-Key header is stored per caller and
+// key, and replayed as-is when the same request is sent again within
+// idempotencyKeyTTL. Reusing a key for a different request is rejected.
+// Server errors are not stored, so the request can be retried for real.
+func (cfg *apiConfig) middlewareIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeySize {
+				respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var owner uuid.NullUUID
+			if viewerID := cfg.viewerID(r); viewerID != uuid.Nil {
+				owner = uuid.NullUUID{UUID: viewerID, Valid: true}
+			}
+			hash := requestHash(r, body)
+
+			claimed, err := cfg.db.ClaimIdempotencyKey(r.Context(), database.ClaimIdempotencyKeyParams{
+				UserID:      owner,
+				Key:         key,
+				RequestHash: hash,
+				TtlSeconds:  int32(idempotencyKeyTTL.Seconds()),
+				LockSeconds: int32(idempotencyLockTimeout.Seconds()),
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+				return
+			}
+			if claimed == 0 {
+				cfg.replayIdempotentResponse(w, r, owner, key, hash)
+				return
+			}
+
+			rec := &responseRecorder{header: w.Header()}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			// The response is stored with a fresh context: the client may
+			// already have given up, which is exactly when it will retry.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if rec.status >= 500 {
+				err = cfg.db.ReleaseIdempotencyKey(ctx, database.ReleaseIdempotencyKeyParams{
+					UserID: owner,
+					Key:    key,
+				})
+			} else {
+				err = cfg.db.SaveIdempotentResponse(ctx, database.SaveIdempotentResponseParams{
+					StatusCode:  sql.NullInt32{Int32: int32(rec.status), Valid: true},
+					ContentType: sql.NullString{String: rec.header.Get("Content-Type"), Valid: true},
+					Body:        rec.body.Bytes(),
+					UserID:      owner,
+					Key:         key,
+				})
+			}
+			if err != nil {
+				log.Printf("Failed to record response for Idempotency-Key %q: %v", key, err)
+			}
+
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+		},
+	)
+}
+
+// requestHash fingerprints a request so a key cannot be reused for a
+// different endpoint or payload.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (cfg *apiConfig) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, owner uuid.NullUUID, key, hash string) {
+	stored, err := cfg.db.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+		UserID: owner,
+		Key:    key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// The first request failed and released the key in between.
+			respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is in progress, retry it")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+		return
+	}
+
+	if stored.RequestHash != hash {
+		respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if !stored.StatusCode.Valid {
+		respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is in progress, retry it")
+		return
+	}
+
+	if stored.ContentType.String != "" {
+		w.Header().Set("Content-Type", stored.ContentType.String)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.Body)
+}
+
+func (cfg *apiConfig) runIdempotencyKeyPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := cfg.db.DeleteExpiredIdempotencyKeys(context.Background(), int32(idempotencyKeyTTL.Seconds()))
+		if err != nil {
+			log.Printf("Failed to purge idempotency keys: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/importer"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImportSize    = 10 << 20
+	maxImportRecords = 10000
+)
+
+// importChirps stores records as public chirps of userID. Each record goes
+// through the same length check and word filter as a new chirp, and records
+// whose external ID was already imported are skipped. Imported chirps are
+// history: they are indexed and reach followers' timelines at their original
+// time, but nobody is notified and nothing is streamed.
+func (cfg *apiConfig) importChirps(ctx context.Context, userID uuid.UUID, records []importer.Record) (ImportReport, error) {
+	report := ImportReport{Errors: []importer.LineError{}}
+
+	for _, rec := range records {
+		body, err := cleanChirpBody(rec.Body)
+		if err != nil {
+			report.Errors = append(report.Errors, importer.LineError{
+				Line:       rec.Line,
+				ExternalID: rec.ExternalID,
+				Message:    err.Error(),
+			})
+			continue
+		}
+
+		imported, err := cfg.importChirp(ctx, userID, rec, body)
+		if err != nil {
+			return ImportReport{}, fmt.Errorf("line %d: %w", rec.Line, err)
+		}
+		if !imported {
+			report.Skipped++
+			continue
+		}
+		report.Imported++
+		if body != rec.Body {
+			report.Censored++
+		}
+	}
+
+	report.Failed = len(report.Errors)
+	return report, nil
+}
+
+func (cfg *apiConfig) importChirp(ctx context.Context, userID uuid.UUID, rec importer.Record, body string) (bool, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	dbChirp, err := q.ImportChirp(ctx, database.ImportChirpParams{
+		CreatedAt:  rec.CreatedAt,
+		Body:       body,
+		UserID:     userID,
+		ExternalID: sql.NullString{String: rec.ExternalID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := indexChirp(ctx, q, dbChirp); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// parseImport parses an archive and enforces the size limit on it.
+func parseImport(r io.Reader, format string) ([]importer.Record, []importer.LineError, error) {
+	records, lineErrors, err := importer.Parse(r, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records)+len(lineErrors) > maxImportRecords {
+		return nil, nil, fmt.Errorf("archives are limited to %d chirps", maxImportRecords)
+	}
+	return records, lineErrors, nil
+}
+
+// mergeImportErrors adds the lines that failed to parse to report, keeping
+// the errors in line order.
+func mergeImportErrors(report *ImportReport, lineErrors []importer.LineError) {
+	report.Errors = append(report.Errors, lineErrors...)
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+	report.Failed = len(report.Errors)
+}
+
+func (cfg *apiConfig) importChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := importer.FormatFromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Send a JSONL (application/x-ndjson) or CSV (text/csv) archive")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	records, lineErrors, err := parseImport(r.Body, format)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Archive exceeds 10 MB")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := cfg.importChirps(r.Context(), userID, records)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to import chirps")
+		return
+	}
+	mergeImportErrors(&report, lineErrors)
+
+	respondWithJson(w, http.StatusOK, report)
+}
+
+// runImportCommand implements "chirpy import", which imports an archive
+// from disk for an existing user and prints the report as JSON.
+func (cfg *apiConfig) runImportCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	email := flags.String("email", "", "email of the user to import chirps for")
+	path := flags.String("file", "", "path to a .jsonl or .csv archive")
+	format := flags.String("format", "", "archive format, jsonl or csv (default: from the file extension)")
+	flags.Parse(args)
+
+	if *email == "" || *path == "" {
+		flags.Usage()
+		return errors.New("-email and -file are required")
+	}
+
+	if *format == "" {
+		f, err := importer.FormatFromFilename(*path)
+		if err != nil {
+			return fmt.Errorf("cannot tell the format of %s; pass -format", *path)
+		}
+		*format = f
+	}
+
+	ctx := context.Background()
+	dbUser, err := cfg.db.GetUserByEmail(ctx, *email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no user with email %s", *email)
+		}
+		return err
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, lineErrors, err := parseImport(file, *format)
+	if err != nil {
+		return err
+	}
+
+	report, err := cfg.importChirps(ctx, dbUser.ID, records)
+	if err != nil {
+		return err
+	}
+	mergeImportErrors(&report, lineErrors)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d lines were not imported", report.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package activitypub implements the parts of ActivityPub, WebFinger and
+// HTTP Signatures that Chirpy needs to federate with other servers.
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType     = "application/activity+json"
+	JRDContentType  = "application/jrd+json"
+	Public          = "https://www.w3.org/ns/activitystreams#Public"
+	activityStreams = "https://www.w3.org/ns/activitystreams"
+	security        = "https://w3id.org/security/v1"
+)
+
+// Context is the JSON-LD context of actor documents, which also carry a
+// public key.
+var Context = []string{activityStreams, security}
+
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type Actor struct {
+	Context           interface{} `json:"@context,omitempty"`
+	ID                string      `json:"id"`
+	Type              string      `json:"type"`
+	PreferredUsername string      `json:"preferredUsername"`
+	Name              string      `json:"name,omitempty"`
+	Summary           string      `json:"summary,omitempty"`
+	URL               string      `json:"url,omitempty"`
+	Inbox             string      `json:"inbox"`
+	Outbox            string      `json:"outbox,omitempty"`
+	Followers         string      `json:"followers,omitempty"`
+	Endpoints         *Endpoints  `json:"endpoints,omitempty"`
+	PublicKey         PublicKey   `json:"publicKey"`
+}
+
+type Note struct {
+	Context      interface{} `json:"@context,omitempty"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	AttributedTo string      `json:"attributedTo"`
+	Content      string      `json:"content"`
+	Published    time.Time   `json:"published"`
+	InReplyTo    string      `json:"inReplyTo,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	To           []string    `json:"to"`
+	Cc           []string    `json:"cc,omitempty"`
+}
+
+type Tombstone struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Activity is an incoming or outgoing activity. Object is kept raw because
+// it can be a bare ID or an embedded object.
+type Activity struct {
+	Context   interface{}     `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	Published *time.Time      `json:"published,omitempty"`
+	To        []string        `json:"to,omitempty"`
+	Cc        []string        `json:"cc,omitempty"`
+}
+
+type OrderedCollection struct {
+	Context      interface{} `json:"@context,omitempty"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	TotalItems   int64       `json:"totalItems"`
+	OrderedItems []Activity  `json:"orderedItems,omitempty"`
+}
+
+// NewActivity wraps object, which is marshaled as-is, in an activity.
+func NewActivity(id, activityType, actor string, object interface{}) (Activity, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return Activity{}, err
+	}
+	return Activity{
+		Context: activityStreams,
+		ID:      id,
+		Type:    activityType,
+		Actor:   actor,
+		Object:  raw,
+	}, nil
+}
+
+// ObjectID returns the ID of the activity's object, whether it was sent as a
+// bare string or as an embedded object.
+func (a Activity) ObjectID() string {
+	var id string
+	if err := json.Unmarshal(a.Object, &id); err == nil {
+		return id
+	}
+
+	var object struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(a.Object, &object)
+	return object.ID
+}
+
+// InnerActivity decodes an embedded activity, such as the Follow inside an
+// Undo.
+func (a Activity) InnerActivity() (Activity, error) {
+	var inner Activity
+	if err := json.Unmarshal(a.Object, &inner); err != nil {
+		return Activity{}, errors.New("object is not an embedded activity")
+	}
+	return inner, nil
+}
+
+type Link struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// JRD is a WebFinger response.
+type JRD struct {
+	Subject string   `json:"subject"`
+	Aliases []string `json:"aliases,omitempty"`
+	Links   []Link   `json:"links"`
+}
+
+// ParseAcct splits a WebFinger resource such as "acct:alice@example.com"
+// into its user and host.
+func ParseAcct(resource string) (string, string, error) {
+	acct, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return "", "", errors.New("resource must be an acct: URI")
+	}
+	acct = strings.TrimPrefix(acct, "@")
+
+	user, host, ok := strings.Cut(acct, "@")
+	if !ok || user == "" || host == "" || strings.Contains(host, "@") {
+		return "", "", errors.New("resource must look like acct:user@host")
+	}
+	return user, strings.ToLower(host), nil
+}
@@ -0,0 +1,63 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAcct(t *testing.T) {
+	tests := []struct {
+		resource string
+		user     string
+		host     string
+		wantErr  bool
+	}{
+		{resource: "acct:alice@chirpy.example", user: "alice", host: "chirpy.example"},
+		{resource: "acct:@alice@Chirpy.Example", user: "alice", host: "chirpy.example"},
+		{resource: "alice@chirpy.example", wantErr: true},
+		{resource: "acct:alice", wantErr: true},
+		{resource: "acct:alice@a@b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			user, host, err := ParseAcct(tt.resource)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if user != tt.user || host != tt.host {
+				t.Errorf("Expected %s@%s, got %s@%s", tt.user, tt.host, user, host)
+			}
+		})
+	}
+}
+
+func TestObjectID(t *testing.T) {
+	t.Run("bare ID", func(t *testing.T) {
+		a := Activity{Object: json.RawMessage(`"https://remote.example/notes/1"`)}
+		if got := a.ObjectID(); got != "https://remote.example/notes/1" {
+			t.Errorf("Expected the note ID, got %q", got)
+		}
+	})
+
+	t.Run("embedded object", func(t *testing.T) {
+		a := Activity{Object: json.RawMessage(`{"id":"https://remote.example/follows/1","type":"Follow","actor":"https://remote.example/users/alice"}`)}
+		if got := a.ObjectID(); got != "https://remote.example/follows/1" {
+			t.Errorf("Expected the follow ID, got %q", got)
+		}
+
+		inner, err := a.InnerActivity()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if inner.Type != "Follow" || inner.Actor != "https://remote.example/users/alice" {
+			t.Errorf("Expected the embedded Follow, got %+v", inner)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const maxResponseSize = 1 << 20
+
+// StatusError is returned when a remote server answers with a non-2xx
+// status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with %d", e.URL, e.StatusCode)
+}
+
+// IsPermanent reports whether retrying a request that failed with err is
+// pointless: the remote server rejected it with a 4xx other than a timeout
+// or rate limit.
+func IsPermanent(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	code := statusErr.StatusCode
+	return code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+}
+
+// Client talks to remote ActivityPub servers.
+type Client struct {
+	http      *http.Client
+	userAgent string
+}
+
+func NewClient(httpClient *http.Client, userAgent string) *Client {
+	return &Client{http: httpClient, userAgent: userAgent}
+}
+
+// Deliver posts a signed activity to a remote inbox.
+func (c *Client) Deliver(ctx context.Context, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	if err := checkURL(inbox); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", c.userAgent)
+	if err := SignRequest(req, activity, keyID, key); err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{URL: inbox, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// FetchActor retrieves a remote actor document. The document must identify
+// itself as the actor at actorURL and own the key it publishes.
+func (c *Client) FetchActor(ctx context.Context, actorURL string) (Actor, error) {
+	if err := checkURL(actorURL); err != nil {
+		return Actor{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actorURL, nil)
+	if err != nil {
+		return Actor{}, err
+	}
+	req.Header.Set("Accept", ContentType+`, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Actor{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Actor{}, &StatusError{URL: actorURL, StatusCode: resp.StatusCode}
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&actor); err != nil {
+		return Actor{}, fmt.Errorf("invalid actor document from %s: %w", actorURL, err)
+	}
+	if actor.ID == "" || actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" {
+		return Actor{}, fmt.Errorf("incomplete actor document from %s", actorURL)
+	}
+	// A server may only speak for the actors it hosts: a document claiming
+	// to be someone else could otherwise replace their stored key.
+	if actor.ID != actorURL || actor.PublicKey.Owner != actor.ID {
+		return Actor{}, fmt.Errorf("actor document from %s claims to be %s", actorURL, actor.ID)
+	}
+	return actor, nil
+}
+
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid remote URL %q", raw)
+	}
+	return nil
+}
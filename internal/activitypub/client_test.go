@@ -0,0 +1,131 @@
+package activitypub
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeliver(t *testing.T) {
+	key := testKey(t)
+	keyID := "https://chirpy.example/ap/users/1#main-key"
+	activity := []byte(`{"type":"Create"}`)
+
+	var received []byte
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, err := VerifyRequest(r, body, func(string) (*rsa.PublicKey, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/gone/inbox" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if r.URL.Path == "/busy/inbox" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		received = body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer remote.Close()
+
+	client := NewClient(remote.Client(), "test")
+
+	t.Run("signed delivery", func(t *testing.T) {
+		err := client.Deliver(context.Background(), remote.URL+"/inbox", activity, keyID, key)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if string(received) != string(activity) {
+			t.Errorf("Expected body %s, got %s", activity, received)
+		}
+	})
+
+	t.Run("permanent failure", func(t *testing.T) {
+		err := client.Deliver(context.Background(), remote.URL+"/gone/inbox", activity, keyID, key)
+		if err == nil || !IsPermanent(err) {
+			t.Errorf("Expected a permanent error, got %v", err)
+		}
+	})
+
+	t.Run("temporary failure", func(t *testing.T) {
+		err := client.Deliver(context.Background(), remote.URL+"/busy/inbox", activity, keyID, key)
+		if err == nil || IsPermanent(err) {
+			t.Errorf("Expected a temporary error, got %v", err)
+		}
+	})
+
+	t.Run("invalid inbox", func(t *testing.T) {
+		err := client.Deliver(context.Background(), "file:///etc/passwd", activity, keyID, key)
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+	})
+}
+
+func TestFetchActor(t *testing.T) {
+	_, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var remote *httptest.Server
+	remote = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /users/mallory serves a document that claims to be alice.
+		if r.URL.Path != "/users/alice" && r.URL.Path != "/users/mallory" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(Actor{
+			ID:                remote.URL + "/users/alice",
+			Type:              "Person",
+			PreferredUsername: "alice",
+			Inbox:             remote.URL + "/users/alice/inbox",
+			PublicKey: PublicKey{
+				ID:           remote.URL + "/users/alice#main-key",
+				Owner:        remote.URL + "/users/alice",
+				PublicKeyPem: publicPEM,
+			},
+		})
+	}))
+	defer remote.Close()
+
+	client := NewClient(remote.Client(), "test")
+
+	t.Run("existing actor", func(t *testing.T) {
+		actor, err := client.FetchActor(context.Background(), remote.URL+"/users/alice")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if actor.PreferredUsername != "alice" {
+			t.Errorf("Expected alice, got %s", actor.PreferredUsername)
+		}
+		if _, err := ParsePublicKey(actor.PublicKey.PublicKeyPem); err != nil {
+			t.Errorf("Expected a valid public key, got %v", err)
+		}
+	})
+
+	t.Run("actor served from another URL", func(t *testing.T) {
+		_, err := client.FetchActor(context.Background(), remote.URL+"/users/mallory")
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+	})
+
+	t.Run("missing actor", func(t *testing.T) {
+		_, err := client.FetchActor(context.Background(), remote.URL+"/users/bob")
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+	})
+}
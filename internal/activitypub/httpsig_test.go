@@ -0,0 +1,112 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	privatePEM, _, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return key
+}
+
+func TestSignAndVerifyRequest(t *testing.T) {
+	key := testKey(t)
+	keyID := "https://remote.example/users/alice#main-key"
+	body := []byte(`{"type":"Follow"}`)
+	lookup := func(id string) (*rsa.PublicKey, error) {
+		if id != keyID {
+			return nil, errors.New("unknown key")
+		}
+		return &key.PublicKey, nil
+	}
+
+	newSigned := func(t *testing.T) *http.Request {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "https://chirpy.example/ap/users/1/inbox", bytes.NewReader(body))
+		if err := SignRequest(req, body, keyID, key); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return req
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		got, err := VerifyRequest(newSigned(t), body, lookup)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != keyID {
+			t.Errorf("Expected key ID %s, got %s", keyID, got)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		_, err := VerifyRequest(newSigned(t), []byte(`{"type":"Delete"}`), lookup)
+		if !errors.Is(err, ErrDigestMismatch) {
+			t.Errorf("Expected ErrDigestMismatch, got %v", err)
+		}
+	})
+
+	t.Run("tampered digest", func(t *testing.T) {
+		req := newSigned(t)
+		other := []byte(`{"type":"Delete"}`)
+		req.Header.Set("Digest", digest(other))
+
+		_, err := VerifyRequest(req, other, lookup)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("different path", func(t *testing.T) {
+		req := newSigned(t)
+		req.URL.Path = "/ap/users/2/inbox"
+
+		_, err := VerifyRequest(req, body, lookup)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("stale date", func(t *testing.T) {
+		req := newSigned(t)
+		req.Header.Set("Date", time.Now().Add(-MaxClockSkew-time.Hour).UTC().Format(http.TimeFormat))
+
+		_, err := VerifyRequest(req, body, lookup)
+		if !errors.Is(err, ErrStaleDate) {
+			t.Errorf("Expected ErrStaleDate, got %v", err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other := testKey(t)
+		_, err := VerifyRequest(newSigned(t), body, func(string) (*rsa.PublicKey, error) {
+			return &other.PublicKey, nil
+		})
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("unsigned request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/ap/users/1/inbox", bytes.NewReader(body))
+
+		_, err := VerifyRequest(req, body, lookup)
+		if !errors.Is(err, ErrMissingSignature) {
+			t.Errorf("Expected ErrMissingSignature, got %v", err)
+		}
+	})
+}
@@ -26,25 +26,35 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	userID, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return userID, err
+}
+
+// ValidateJWTWithExpiry is ValidateJWT that also returns when the token
+// expires, for connections that outlive a single request.
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	claims := &jwt.RegisteredClaims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
 		return []byte(tokenSecret), nil
 	})
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 
 	claims, ok := token.Claims.(*jwt.RegisteredClaims)
 	if !ok {
-		return uuid.Nil, errors.New("invalid token claims")
+		return uuid.Nil, time.Time{}, errors.New("invalid token claims")
+	}
+	if claims.ExpiresAt == nil {
+		return uuid.Nil, time.Time{}, errors.New("token has no expiry")
 	}
 
 	id := claims.Subject
 	uuID, err := uuid.Parse(id)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 
-	return uuID, nil
+	return uuID, claims.ExpiresAt.Time, nil
 }
@@ -244,6 +244,43 @@ func TestValidateJWT(t *testing.T) {
 	})
 }
 
+func TestValidateJWTWithExpiry(t *testing.T) {
+	userID := uuid.New()
+	tokenSecret := "test-secret-key"
+
+	t.Run("returns expiry", func(t *testing.T) {
+		before := time.Now().Add(time.Hour).Truncate(time.Second)
+		token, err := MakeJWT(userID, tokenSecret, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+
+		validatedUserID, expiresAt, err := ValidateJWTWithExpiry(token, tokenSecret)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if validatedUserID != userID {
+			t.Errorf("Expected user ID %s, got %s", userID, validatedUserID)
+		}
+		if expiresAt.Before(before) || expiresAt.After(time.Now().Add(time.Hour)) {
+			t.Errorf("Expected expiry about an hour from now, got %v", expiresAt)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token, err := MakeJWT(userID, tokenSecret, -time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+
+		_, _, err = ValidateJWTWithExpiry(token, tokenSecret)
+		if err == nil {
+			t.Fatal("Expected error for expired token")
+		}
+	})
+}
+
 func TestMakeJWTAndValidateJWTIntegration(t *testing.T) {
 	t.Run("round trip test", func(t *testing.T) {
 		userID := uuid.New()
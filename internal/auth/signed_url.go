@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("signature has expired")
+)
+
+// SignURL returns the query string that authorizes requests for path until
+// expiresAt, such as "expires=1700000000&signature=...".
+func SignURL(path string, expiresAt time.Time, secret string) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", hex.EncodeToString(urlMAC(path, expires, secret)))
+	return query.Encode()
+}
+
+// ValidateSignedURL checks the expires and signature parameters that SignURL
+// added to path.
+func ValidateSignedURL(path string, query url.Values, secret string) error {
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, urlMAC(path, expires, secret)) {
+		return ErrInvalidSignature
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// urlMAC prefixes its input so a URL signature can never be mistaken for a
+// signature made with the same secret for another purpose.
+func urlMAC(path, expires, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("chirpy-url\n" + path + "\n" + expires))
+	return mac.Sum(nil)
+}
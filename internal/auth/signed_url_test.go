@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignedURL(t *testing.T) {
+	secret := "test-secret-key"
+	path := "/api/me/export/123/download"
+
+	t.Run("valid signature", func(t *testing.T) {
+		query, err := url.ParseQuery(SignURL(path, time.Now().Add(time.Hour), secret))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := ValidateSignedURL(path, query, secret); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("expired signature", func(t *testing.T) {
+		query, _ := url.ParseQuery(SignURL(path, time.Now().Add(-time.Minute), secret))
+
+		err := ValidateSignedURL(path, query, secret)
+		if !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("Expected ErrSignatureExpired, got %v", err)
+		}
+	})
+
+	t.Run("different path", func(t *testing.T) {
+		query, _ := url.ParseQuery(SignURL(path, time.Now().Add(time.Hour), secret))
+
+		err := ValidateSignedURL("/api/me/export/456/download", query, secret)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("extended expiry", func(t *testing.T) {
+		query, _ := url.ParseQuery(SignURL(path, time.Now().Add(time.Hour), secret))
+		query.Set("expires", "9999999999")
+
+		err := ValidateSignedURL(path, query, secret)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		query, _ := url.ParseQuery(SignURL(path, time.Now().Add(time.Hour), secret))
+
+		err := ValidateSignedURL(path, query, "wrong-secret")
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("missing parameters", func(t *testing.T) {
+		err := ValidateSignedURL(path, url.Values{}, secret)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// A hashtag starts at the beginning of the body or after a character that
+// cannot be part of a word, so "a#b" and "&#39;" are not tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+const maxTagLength = 100
+
+// ExtractHashtags returns the distinct hashtags in body, lowercased and
+// without the leading '#', in order of first appearance.
+func ExtractHashtags(body string) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := NormalizeTag(match[1])
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// NormalizeTag lowercases tag and strips a leading '#'. It returns "" for
+// strings that are not valid tags, such as purely numeric ones.
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || len(tag) > maxTagLength {
+		return ""
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r) || r == '_':
+		default:
+			return ""
+		}
+	}
+	if !hasLetter {
+		return ""
+	}
+
+	return tag
+}
@@ -0,0 +1,43 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"no tags", "just a chirp", []string{}},
+		{"single tag", "hello #Gophers", []string{"gophers"}},
+		{"tag at start", "#go is fun", []string{"go"}},
+		{"duplicates collapse", "#Go #go #GO", []string{"go"}},
+		{"keeps order", "#b then #a then #b", []string{"b", "a"}},
+		{"punctuation ends tag", "love #golang, really", []string{"golang"}},
+		{"unicode letters", "bonjour #café", []string{"café"}},
+		{"not inside words", "email a#b and &#39;", []string{}},
+		{"numeric only ignored", "we are #1 at #go2", []string{"go2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractHashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	if got := NormalizeTag("#GoLang"); got != "golang" {
+		t.Errorf("Expected golang, got %s", got)
+	}
+
+	if got := NormalizeTag("not-a-tag"); got != "" {
+		t.Errorf("Expected empty tag, got %s", got)
+	}
+}
@@ -0,0 +1,49 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	handlePattern  = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])(@[A-Za-z0-9_]{3,30})\b`)
+)
+
+// Mention is an "@handle" token in a chirp body. Start and End are character
+// offsets into the body, End exclusive, covering the '@' and the handle.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ValidHandle reports whether handle can be registered by a user.
+func ValidHandle(handle string) bool {
+	return handlePattern.MatchString(handle)
+}
+
+// ExtractMentions returns every "@handle" token in body in order of
+// appearance. Handles are lowercased so they can be matched
+// case-insensitively.
+func ExtractMentions(body string) []Mention {
+	mentions := []Mention{}
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		start, end := loc[2], loc[3]
+		runeStart := utf8.RuneCountInString(body[:start])
+		mentions = append(mentions, Mention{
+			Handle: NormalizeHandle(body[start+1 : end]),
+			Start:  runeStart,
+			End:    runeStart + utf8.RuneCountInString(body[start:end]),
+		})
+	}
+
+	return mentions
+}
+
+// NormalizeHandle returns the form of handle used for case-insensitive
+// comparisons.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(handle)
+}
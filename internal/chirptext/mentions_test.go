@@ -0,0 +1,48 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		{"no mentions", "hello world", []Mention{}},
+		{"single mention", "hi @Saul_G!", []Mention{{Handle: "saul_g", Start: 3, End: 10}}},
+		{"mention at start", "@kim look", []Mention{{Handle: "kim", Start: 0, End: 4}}},
+		{"repeated mention", "@kim and @kim", []Mention{
+			{Handle: "kim", Start: 0, End: 4},
+			{Handle: "kim", Start: 9, End: 13},
+		}},
+		{"character offsets", "héllo @kim", []Mention{{Handle: "kim", Start: 6, End: 10}}},
+		{"email is not a mention", "mail saul@example.com", []Mention{}},
+		{"too short", "hey @ab", []Mention{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	for _, handle := range []string{"saul", "Kim_Wexler", "abc"} {
+		if !ValidHandle(handle) {
+			t.Errorf("Expected %q to be valid", handle)
+		}
+	}
+
+	for _, handle := range []string{"", "ab", "has space", "dash-ed", "waytoolonghandlewaytoolonghandle"} {
+		if ValidHandle(handle) {
+			t.Errorf("Expected %q to be invalid", handle)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a list ordered by (created_at DESC, id DESC).
+// The zero Cursor means "start from the newest item".
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Start is the position before the newest possible item.
+func Start() Cursor {
+	return Cursor{
+		CreatedAt: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC),
+		ID:        uuid.Max,
+	}
+}
+
+func Encode(c Cursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses an encoded cursor. An empty string decodes to Start().
+func Decode(s string) (Cursor, error) {
+	if s == "" {
+		return Start(), nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 {
+		return Cursor{}, errInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	return Cursor{CreatedAt: createdAt, ID: id}, nil
+}
@@ -0,0 +1,48 @@
+package cursor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		want := Cursor{
+			CreatedAt: time.Date(2025, time.March, 4, 5, 6, 7, 891011, time.UTC),
+			ID:        uuid.New(),
+		}
+
+		got, err := Decode(Encode(want))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("Expected created_at %v, got %v", want.CreatedAt, got.CreatedAt)
+		}
+		if got.ID != want.ID {
+			t.Errorf("Expected id %s, got %s", want.ID, got.ID)
+		}
+	})
+
+	t.Run("empty cursor starts from newest", func(t *testing.T) {
+		got, err := Decode("")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if got != Start() {
+			t.Errorf("Expected start cursor, got %v", got)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, s := range []string{"!!!", "bm90LWEtY3Vyc29y", Encode(Cursor{})[:5]} {
+			if _, err := Decode(s); err == nil {
+				t.Errorf("Expected error for cursor %q", s)
+			}
+		}
+	})
+}
@@ -7,29 +7,40 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, original_chirp_id, reply_to_id, visibility)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body            string
+	UserID          uuid.UUID
+	Kind            string
+	OriginalChirpID uuid.NullUUID
+	ReplyToID       uuid.NullUUID
+	Visibility      string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.Kind, arg.OriginalChirpID, arg.ReplyToID, arg.Visibility)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,27 +48,46 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
 	)
 	return i, err
 }
 
-const deleteChirpByID = `-- name: DeleteChirpByID :exec
-DELETE FROM chirps
-WHERE id = $1
-`
-
-func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpByID, id)
-	return err
-}
-
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $1)
+    OR (user_blocks.blocker_id = $1 AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $1 AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = $1
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $1 AND follows.followee_id = chirps.user_id
+    ))
+)
 ORDER BY created_at
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetAllChirps(ctx context.Context, viewerID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +101,16 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
 		); err != nil {
 			return nil, err
 		}
@@ -86,8 +126,8 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -99,18 +139,168 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
 	)
 	return i, err
 }
 
+const getChirpForViewer = `-- name: GetChirpForViewer :one
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE id = $1
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2)
+    OR (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = chirps.user_id)
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = $2
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+`
+
+type GetChirpForViewerParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpForViewer(ctx context.Context, arg GetChirpForViewerParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForViewer, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE id = ANY($1::uuid[])
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2)
+    OR (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $2 AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = $2
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
 WHERE user_id = $1
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2)
+    OR (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $2 AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = $2
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
 ORDER BY created_at
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type GetChirpsByUserIDParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsByUserID(ctx context.Context, arg GetChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, arg.UserID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +314,16 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
 		); err != nil {
 			return nil, err
 		}
@@ -137,3 +337,361 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const getFeedChirps = `-- name: GetFeedChirps :many
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND visibility = 'public'
+AND kind <> 'rechirp'
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type GetFeedChirpsParams struct {
+	UserID   uuid.NullUUID
+	PageSize int32
+}
+
+func (q *Queries) GetFeedChirps(ctx context.Context, arg GetFeedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedChirps, arg.UserID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrash = `-- name: GetTrash :many
+SELECT id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id FROM chirps
+WHERE user_id = $1
+AND deleted_at > NOW() - make_interval(secs => $2::int)
+AND (deleted_at, id) < ($3::timestamp, $4::uuid)
+ORDER BY deleted_at DESC, id DESC
+LIMIT $5
+`
+
+type GetTrashParams struct {
+	UserID          uuid.UUID
+	WindowSeconds   int32
+	BeforeDeletedAt time.Time
+	BeforeID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetTrash(ctx context.Context, arg GetTrashParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTrash, arg.UserID, arg.WindowSeconds, arg.BeforeDeletedAt, arg.BeforeID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedChirp = `-- name: GetTrashedChirp :one
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.like_count, chirps.kind, chirps.original_chirp_id, chirps.reply_to_id, chirps.status, chirps.publish_at, chirps.visibility, chirps.pinned_at, chirps.deleted_at, chirps.external_id, (deleted_at > NOW() - make_interval(secs => $1::int))::boolean AS restorable
+FROM chirps
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NOT NULL
+FOR UPDATE
+`
+
+type GetTrashedChirpParams struct {
+	WindowSeconds int32
+	ID            uuid.UUID
+	UserID        uuid.UUID
+}
+
+type GetTrashedChirpRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Body            string
+	UserID          uuid.UUID
+	LikeCount       int32
+	Kind            string
+	OriginalChirpID uuid.NullUUID
+	ReplyToID       uuid.NullUUID
+	Status          string
+	PublishAt       sql.NullTime
+	Visibility      string
+	PinnedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	ExternalID      sql.NullString
+	Restorable      bool
+}
+
+func (q *Queries) GetTrashedChirp(ctx context.Context, arg GetTrashedChirpParams) (GetTrashedChirpRow, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedChirp, arg.WindowSeconds, arg.ID, arg.UserID)
+	var i GetTrashedChirpRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
+		&i.Restorable,
+	)
+	return i, err
+}
+
+const importChirp = `-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, visibility, external_id)
+VALUES(
+    gen_random_uuid(),
+    $1,
+    $1,
+    $2,
+    $3,
+    'original',
+    'public',
+    $4
+)
+ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id
+`
+
+type ImportChirpParams struct {
+	CreatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ExternalID sql.NullString
+}
+
+func (q *Queries) ImportChirp(ctx context.Context, arg ImportChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, importChirp, arg.CreatedAt, arg.Body, arg.UserID, arg.ExternalID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
+	)
+	return i, err
+}
+
+const lockExpiredTrash = `-- name: LockExpiredTrash :many
+SELECT id FROM chirps
+WHERE deleted_at <= NOW() - make_interval(secs => $1::int)
+ORDER BY deleted_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type LockExpiredTrashParams struct {
+	WindowSeconds int32
+	MaxChirps     int32
+}
+
+func (q *Queries) LockExpiredTrash(ctx context.Context, arg LockExpiredTrashParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, lockExpiredTrash, arg.WindowSeconds, arg.MaxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeChirps = `-- name: PurgeChirps :exec
+DELETE FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) PurgeChirps(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, purgeChirps, pq.Array(ids))
+	return err
+}
+
+const restoreChirp = `-- name: RestoreChirp :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (id = $1 OR (kind = 'rechirp' AND original_chirp_id = $1))
+AND deleted_at = $2::timestamp
+`
+
+type RestoreChirpParams struct {
+	ID        uuid.UUID
+	DeletedAt time.Time
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) error {
+	_, err := q.db.ExecContext(ctx, restoreChirp, arg.ID, arg.DeletedAt)
+	return err
+}
+
+const trashChirp = `-- name: TrashChirp :many
+UPDATE chirps
+SET deleted_at = NOW(), pinned_at = NULL
+WHERE (id = $1 OR (kind = 'rechirp' AND original_chirp_id = $1))
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id
+`
+
+func (q *Queries) TrashChirp(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, trashChirp, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.Kind,
+			&i.OriginalChirpID,
+			&i.ReplyToID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.DeletedAt,
+			&i.ExternalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, like_count, kind, original_chirp_id, reply_to_id, status, publish_at, visibility, pinned_at, deleted_at, external_id
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.Kind,
+		&i.OriginalChirpID,
+		&i.ReplyToID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.DeletedAt,
+		&i.ExternalID,
+	)
+	return i, err
+}
@@ -11,12 +11,190 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApDelivery struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	InboxUrl      string
+	Activity      string
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	CreatedAt     time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Body            string
+	UserID          uuid.UUID
+	LikeCount       int32
+	Kind            string
+	OriginalChirpID uuid.NullUUID
+	ReplyToID       uuid.NullUUID
+	Status          string
+	PublishAt       sql.NullTime
+	Visibility      string
+	PinnedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	ExternalID      sql.NullString
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMedium struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Conversation struct {
 	ID        uuid.UUID
+	DirectKey sql.NullString
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	CreatedAt   time.Time
+	StartedAt   sql.NullTime
+	CompletedAt sql.NullTime
+	ExpiresAt   sql.NullTime
+	StorageKey  sql.NullString
+	SizeBytes   sql.NullInt64
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type IdempotencyKey struct {
+	UserID      uuid.NullUUID
+	Key         string
+	RequestHash string
+	StatusCode  sql.NullInt32
+	ContentType sql.NullString
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+type List struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	StorageKey   string
+	ThumbnailKey string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.NullUUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Type      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Type      string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	Multiple  bool
+	ClosesAt  time.Time
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+	Position int32
+}
+
+type PollVoter struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +206,92 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type RemoteActor struct {
+	ActorUrl       string
+	InboxUrl       string
+	SharedInboxUrl sql.NullString
+	KeyID          string
+	PublicKeyPem   string
+	FetchedAt      time.Time
+}
+
+type RemoteFollower struct {
+	UserID    uuid.UUID
+	ActorUrl  string
+	CreatedAt time.Time
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type TrendingTag struct {
+	TimeWindow string
+	Tag        string
+	ChirpCount int64
+	Rank       int32
+	ComputedAt time.Time
+}
+
 type User struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Email                string
+	HashedPassword       string
+	ChirpyRed            bool
+	Handle               sql.NullString
+	DisplayName          string
+	Bio                  string
+	AvatarUrl            string
+	Location             string
+	DmsFromFollowersOnly bool
+	DeleteAfter          sql.NullTime
+	ApPrivateKey         sql.NullString
+	ApPublicKey          sql.NullString
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
 	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	EventID        uuid.UUID
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	ChirpyRed      bool
+}
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	Url       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+type WebhookEvent struct {
+	ID           uuid.UUID
+	UserID       uuid.NullUUID
+	Type         string
+	Payload      string
+	DispatchedAt sql.NullTime
+	CreatedAt    time.Time
 }
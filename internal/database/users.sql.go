@@ -7,51 +7,167 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :execrows
+UPDATE users
+SET delete_after = NULL, updated_at = NOW()
+WHERE id = $1 AND delete_after > NOW()
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, chirpy_red, handle, display_name, bio, avatar_url, location, dms_from_followers_only, delete_after, ap_private_key, ap_public_key
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
-type CreateUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Email     string
-	ChirpyRed bool
-}
-
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
-	var i CreateUserRow
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Email,
+		&i.HashedPassword,
+		&i.ChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.DmsFromFollowersOnly,
+		&i.DeleteAfter,
+		&i.ApPrivateKey,
+		&i.ApPublicKey,
+	)
+	return i, err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getHandlesByIDs = `-- name: GetHandlesByIDs :many
+SELECT id, handle FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+type GetHandlesByIDsRow struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) GetHandlesByIDs(ctx context.Context, ids []uuid.UUID) ([]GetHandlesByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getHandlesByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetHandlesByIDsRow
+	for rows.Next() {
+		var i GetHandlesByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublicProfileByHandle = `-- name: GetPublicProfileByHandle :one
+SELECT
+    users.id,
+    users.created_at,
+    users.handle,
+    users.display_name,
+    users.bio,
+    users.avatar_url,
+    users.location,
+    users.chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND chirps.deleted_at IS NULL AND chirps.visibility IN ('public', 'unlisted')) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE LOWER(users.handle) = LOWER($1::text)
+`
+
+type GetPublicProfileByHandleRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	Location       string
+	ChirpyRed      bool
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetPublicProfileByHandle(ctx context.Context, handle string) (GetPublicProfileByHandleRow, error) {
+	row := q.db.QueryRowContext(ctx, getPublicProfileByHandle, handle)
+	var i GetPublicProfileByHandleRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
 		&i.ChirpyRed,
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, chirpy_red, handle, display_name, bio, avatar_url, location, dms_from_followers_only, delete_after, ap_private_key, ap_public_key FROM users
 WHERE email = $1
 `
 
@@ -65,10 +181,121 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.ChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.DmsFromFollowersOnly,
+		&i.DeleteAfter,
+		&i.ApPrivateKey,
+		&i.ApPublicKey,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, chirpy_red, handle, display_name, bio, avatar_url, location, dms_from_followers_only, delete_after, ap_private_key, ap_public_key FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.ChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.DmsFromFollowersOnly,
+		&i.DeleteAfter,
+		&i.ApPrivateKey,
+		&i.ApPublicKey,
 	)
 	return i, err
 }
 
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, chirpy_red, handle, display_name, bio, avatar_url, location, dms_from_followers_only, delete_after, ap_private_key, ap_public_key FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.ChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.DmsFromFollowersOnly,
+		&i.DeleteAfter,
+		&i.ApPrivateKey,
+		&i.ApPublicKey,
+	)
+	return i, err
+}
+
+const isActiveUser = `-- name: IsActiveUser :one
+SELECT EXISTS (
+    SELECT 1 FROM users
+    WHERE id = $1 AND delete_after IS NULL
+)
+`
+
+func (q *Queries) IsActiveUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isActiveUser, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const lockDueUserDeletions = `-- name: LockDueUserDeletions :many
+SELECT id FROM users
+WHERE delete_after <= NOW()
+ORDER BY delete_after
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) LockDueUserDeletions(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, lockDueUserDeletions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetUser = `-- name: ResetUser :exec
 DELETE FROM users
 `
@@ -78,47 +305,100 @@ func (q *Queries) ResetUser(ctx context.Context) error {
 	return err
 }
 
-const updatePasswordByID = `-- name: UpdatePasswordByID :one
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET delete_after = NOW() + make_interval(secs => $1::int), updated_at = NOW()
+WHERE id = $2
+RETURNING delete_after
+`
+
+type ScheduleUserDeletionParams struct {
+	GraceSeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.GraceSeconds, arg.ID)
+	var delete_after sql.NullTime
+	err := row.Scan(&delete_after)
+	return delete_after, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
-SET hashed_password = $1, updated_at = NOW(), email = $2
-WHERE id = $3
-RETURNING id, created_at, updated_at, email, chirpy_red
+SET hashed_password = $1, updated_at = NOW()
+WHERE id = $2
 `
 
-type UpdatePasswordByIDParams struct {
+type UpdateUserPasswordParams struct {
 	HashedPassword string
-	Email          string
 	ID             uuid.UUID
 }
 
-type UpdatePasswordByIDRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Email     string
-	ChirpyRed bool
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.HashedPassword, arg.ID)
+	return err
 }
 
-func (q *Queries) UpdatePasswordByID(ctx context.Context, arg UpdatePasswordByIDParams) (UpdatePasswordByIDRow, error) {
-	row := q.db.QueryRowContext(ctx, updatePasswordByID, arg.HashedPassword, arg.Email, arg.ID)
-	var i UpdatePasswordByIDRow
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    email = COALESCE($1::text, email),
+    handle = COALESCE($2::text, handle),
+    display_name = COALESCE($3::text, display_name),
+    bio = COALESCE($4::text, bio),
+    avatar_url = COALESCE($5::text, avatar_url),
+    location = COALESCE($6::text, location),
+    dms_from_followers_only = COALESCE($7::boolean, dms_from_followers_only),
+    updated_at = NOW()
+WHERE id = $8
+RETURNING id, created_at, updated_at, email, hashed_password, chirpy_red, handle, display_name, bio, avatar_url, location, dms_from_followers_only, delete_after, ap_private_key, ap_public_key
+`
+
+type UpdateUserProfileParams struct {
+	Email                sql.NullString
+	Handle               sql.NullString
+	DisplayName          sql.NullString
+	Bio                  sql.NullString
+	AvatarUrl            sql.NullString
+	Location             sql.NullString
+	DmsFromFollowersOnly sql.NullBool
+	ID                   uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.Email, arg.Handle, arg.DisplayName, arg.Bio, arg.AvatarUrl, arg.Location, arg.DmsFromFollowersOnly, arg.ID)
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Email,
+		&i.HashedPassword,
 		&i.ChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.DmsFromFollowersOnly,
+		&i.DeleteAfter,
+		&i.ApPrivateKey,
+		&i.ApPublicKey,
 	)
 	return i, err
 }
 
-const upgradeUserByID = `-- name: UpgradeUserByID :exec
+const upgradeUserByID = `-- name: UpgradeUserByID :execrows
 UPDATE users
 SET chirpy_red = TRUE, updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND chirpy_red = FALSE
 `
 
-func (q *Queries) UpgradeUserByID(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserByID, id)
-	return err
+func (q *Queries) UpgradeUserByID(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upgradeUserByID, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
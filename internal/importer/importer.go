@@ -0,0 +1,224 @@
+// Package importer reads archives of chirps brought over from other
+// platforms. Archives are JSONL, one object per line, or CSV with a header
+// row; both carry external_id, body and created_at (RFC 3339) for each chirp.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+
+	maxExternalIDLength = 255
+	maxLineSize         = 1 << 20
+)
+
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// Record is one chirp from an archive. Line is where it starts, counting
+// from 1 and including any CSV header.
+type Record struct {
+	Line       int
+	ExternalID string
+	Body       string
+	CreatedAt  time.Time
+}
+
+// LineError reports why one line of an archive was not imported.
+type LineError struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id,omitempty"`
+	Message    string `json:"error"`
+}
+
+type rawRecord struct {
+	ExternalID string `json:"external_id"`
+	Body       string `json:"body"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// FormatFromContentType maps a request Content-Type to an archive format.
+func FormatFromContentType(contentType string) (string, error) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatJSONL, nil
+	case "text/csv":
+		return FormatCSV, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// FormatFromFilename picks an archive format from a file extension.
+func FormatFromFilename(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse reads every record in r. Lines that cannot be parsed or fail
+// validation are returned as LineErrors and do not stop the rest of the
+// archive; the error is only set when r itself cannot be read.
+func Parse(r io.Reader, format string) ([]Record, []LineError, error) {
+	p := &parser{seen: make(map[string]int)}
+
+	var err error
+	switch format {
+	case FormatJSONL:
+		err = p.parseJSONL(r)
+	case FormatCSV:
+		err = p.parseCSV(r)
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.records, p.errors, nil
+}
+
+type parser struct {
+	records []Record
+	errors  []LineError
+	// seen maps external IDs to the line that first used them.
+	seen map[string]int
+}
+
+func (p *parser) fail(line int, externalID, format string, args ...interface{}) {
+	p.errors = append(p.errors, LineError{
+		Line:       line,
+		ExternalID: externalID,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (p *parser) add(line int, raw rawRecord) {
+	externalID := strings.TrimSpace(raw.ExternalID)
+	switch {
+	case externalID == "":
+		p.fail(line, "", "external_id is required")
+		return
+	case len(externalID) > maxExternalIDLength:
+		p.fail(line, "", "external_id exceeds %d characters", maxExternalIDLength)
+		return
+	}
+
+	if first, ok := p.seen[externalID]; ok {
+		p.fail(line, externalID, "external_id already used on line %d", first)
+		return
+	}
+	p.seen[externalID] = line
+
+	if strings.TrimSpace(raw.Body) == "" {
+		p.fail(line, externalID, "body is required")
+		return
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, strings.TrimSpace(raw.CreatedAt))
+	if err != nil {
+		p.fail(line, externalID, "created_at must be an RFC 3339 timestamp")
+		return
+	}
+	if createdAt.After(time.Now()) {
+		p.fail(line, externalID, "created_at is in the future")
+		return
+	}
+
+	p.records = append(p.records, Record{
+		Line:       line,
+		ExternalID: externalID,
+		Body:       raw.Body,
+		CreatedAt:  createdAt.UTC(),
+	})
+}
+
+func (p *parser) parseJSONL(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var raw rawRecord
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			p.fail(line, "", "invalid JSON")
+			continue
+		}
+		p.add(line, raw)
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return fmt.Errorf("line %d exceeds %d bytes", line+1, maxLineSize)
+	}
+	return scanner.Err()
+}
+
+func (p *parser) parseCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"external_id", "body", "created_at"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("CSV header is missing the %s column", name)
+		}
+	}
+	field := func(fields []string, name string) string {
+		if i := columns[name]; i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			p.fail(parseErr.StartLine, "", "invalid CSV: %v", parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		p.add(line, rawRecord{
+			ExternalID: field(fields, "external_id"),
+			Body:       field(fields, "body"),
+			CreatedAt:  field(fields, "created_at"),
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSONL(t *testing.T) {
+	archive := strings.Join([]string{
+		`{"external_id": "1", "body": "first chirp", "created_at": "2020-01-02T03:04:05Z"}`,
+		``,
+		`not json`,
+		`{"external_id": "2", "body": "   ", "created_at": "2020-01-02T03:04:05Z"}`,
+		`{"external_id": "3", "body": "bad time", "created_at": "yesterday"}`,
+		`{"external_id": "1", "body": "again", "created_at": "2020-01-02T03:04:05Z"}`,
+		`{"external_id": "4", "body": "from the future", "created_at": "2999-01-01T00:00:00Z"}`,
+		`{"body": "no id", "created_at": "2020-01-02T03:04:05Z"}`,
+		`{"external_id": "5", "body": "offset", "created_at": "2020-01-02T05:04:05+02:00"}`,
+	}, "\n")
+
+	records, lineErrors, err := Parse(strings.NewReader(archive), FormatJSONL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Line != 1 || records[0].ExternalID != "1" || records[0].Body != "first chirp" {
+		t.Errorf("Unexpected first record %+v", records[0])
+	}
+	want := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	if !records[1].CreatedAt.Equal(want) || records[1].CreatedAt.Location() != time.UTC {
+		t.Errorf("Expected created_at %v in UTC, got %v", want, records[1].CreatedAt)
+	}
+
+	wantLines := []int{3, 4, 5, 6, 7, 8}
+	if len(lineErrors) != len(wantLines) {
+		t.Fatalf("Expected %d line errors, got %d: %+v", len(wantLines), len(lineErrors), lineErrors)
+	}
+	for i, line := range wantLines {
+		if lineErrors[i].Line != line {
+			t.Errorf("Expected error %d on line %d, got line %d", i, line, lineErrors[i].Line)
+		}
+	}
+	if lineErrors[3].Message != "external_id already used on line 1" {
+		t.Errorf("Expected duplicate error, got %q", lineErrors[3].Message)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	t.Run("columns in any order", func(t *testing.T) {
+		archive := "created_at,external_id,extra,body\n" +
+			"2020-01-02T03:04:05Z,a,x,hello\n" +
+			"2020-01-02T03:04:05Z,b,x,\"spans\ntwo lines\"\n" +
+			"2020-01-02T03:04:05Z,,x,missing id\n"
+
+		records, lineErrors, err := Parse(strings.NewReader(archive), FormatCSV)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 records, got %d", len(records))
+		}
+		if records[0].Line != 2 || records[0].Body != "hello" {
+			t.Errorf("Unexpected first record %+v", records[0])
+		}
+		if records[1].Line != 3 || records[1].Body != "spans\ntwo lines" {
+			t.Errorf("Unexpected second record %+v", records[1])
+		}
+		if len(lineErrors) != 1 || lineErrors[0].Line != 5 {
+			t.Errorf("Expected one error on line 5, got %+v", lineErrors)
+		}
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, _, err := Parse(strings.NewReader("external_id,body\n1,hi\n"), FormatCSV)
+		if err == nil {
+			t.Error("Expected an error for a missing created_at column")
+		}
+	})
+
+	t.Run("empty archive", func(t *testing.T) {
+		records, lineErrors, err := Parse(strings.NewReader(""), FormatCSV)
+		if err != nil || len(records) != 0 || len(lineErrors) != 0 {
+			t.Errorf("Expected nothing, got %v, %v, %v", records, lineErrors, err)
+		}
+	})
+}
+
+func TestFormats(t *testing.T) {
+	if _, _, err := Parse(strings.NewReader(""), "xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/x-ndjson", FormatJSONL},
+		{"text/csv; charset=utf-8", FormatCSV},
+		{"application/json", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, _ := FormatFromContentType(tt.contentType)
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if got, _ := FormatFromFilename("tweets.NDJSON"); got != FormatJSONL {
+		t.Errorf("Expected %q, got %q", FormatJSONL, got)
+	}
+}
@@ -0,0 +1,293 @@
+// Package media validates uploaded images and re-encodes them without
+// metadata, producing a thumbnail alongside.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxPixels bounds decoded image size so a small, highly compressed file
+	// cannot exhaust memory.
+	MaxPixels     = 40_000_000
+	ThumbnailSize = 320
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+type Image struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Width       int
+	Height      int
+
+	ThumbnailContentType string
+	ThumbnailExt         string
+	Thumbnail            []byte
+}
+
+// Process sniffs the content type of data, which must be a JPEG, PNG or GIF,
+// and returns the re-encoded image and its thumbnail. Re-encoding drops EXIF
+// and every other metadata block; a JPEG's EXIF orientation is applied to the
+// pixels first so the image still displays upright.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+
+	var decodeConfig func([]byte) (image.Config, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+	case "image/png":
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+	case "image/gif":
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+	default:
+		return Image{}, ErrUnsupportedType
+	}
+
+	config, err := decodeConfig(data)
+	if err != nil {
+		return Image{}, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	switch contentType {
+	case "image/jpeg":
+		return processJPEG(data)
+	case "image/png":
+		return processPNG(data)
+	default:
+		return processGIF(data)
+	}
+}
+
+func processJPEG(data []byte) (Image, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, err
+	}
+	img = orient(img, jpegOrientation(data))
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: 90}); err != nil {
+		return Image{}, err
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, thumbnail(img), &jpeg.Options{Quality: 80}); err != nil {
+		return Image{}, err
+	}
+
+	b := img.Bounds()
+	return Image{
+		ContentType:          "image/jpeg",
+		Ext:                  ".jpg",
+		Data:                 out.Bytes(),
+		Width:                b.Dx(),
+		Height:               b.Dy(),
+		ThumbnailContentType: "image/jpeg",
+		ThumbnailExt:         ".jpg",
+		Thumbnail:            thumb.Bytes(),
+	}, nil
+}
+
+func processPNG(data []byte) (Image, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, err
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return Image{}, err
+	}
+
+	var thumb bytes.Buffer
+	if err := png.Encode(&thumb, thumbnail(img)); err != nil {
+		return Image{}, err
+	}
+
+	b := img.Bounds()
+	return Image{
+		ContentType:          "image/png",
+		Ext:                  ".png",
+		Data:                 out.Bytes(),
+		Width:                b.Dx(),
+		Height:               b.Dy(),
+		ThumbnailContentType: "image/png",
+		ThumbnailExt:         ".png",
+		Thumbnail:            thumb.Bytes(),
+	}, nil
+}
+
+// processGIF keeps animation; only the first frame is used for the thumbnail.
+// gif.DecodeAll holds every frame in memory, so the frames are sized up from
+// the block structure first and MaxPixels applies to all of them together.
+func processGIF(data []byte) (Image, error) {
+	pixels, err := gifPixels(data)
+	if err != nil {
+		return Image{}, err
+	}
+	if pixels > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, err
+	}
+	if len(g.Image) == 0 {
+		return Image{}, errors.New("gif has no frames")
+	}
+
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, g); err != nil {
+		return Image{}, err
+	}
+
+	first := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(first, first.Bounds(), g.Image[0], image.Point{}, draw.Over)
+
+	var thumb bytes.Buffer
+	if err := png.Encode(&thumb, thumbnail(first)); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType:          "image/gif",
+		Ext:                  ".gif",
+		Data:                 out.Bytes(),
+		Width:                g.Config.Width,
+		Height:               g.Config.Height,
+		ThumbnailContentType: "image/png",
+		ThumbnailExt:         ".png",
+		Thumbnail:            thumb.Bytes(),
+	}, nil
+}
+
+// gifPixels walks the blocks of a GIF without decoding any image data and
+// returns the summed size of its frames.
+func gifPixels(data []byte) (int64, error) {
+	errTruncated := errors.New("gif is truncated")
+	if len(data) < 13 {
+		return 0, errTruncated
+	}
+
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << ((flags & 0x07) + 1)
+	}
+
+	// skipSubBlocks moves pos past a run of data sub-blocks.
+	skipSubBlocks := func() error {
+		for {
+			if pos >= len(data) {
+				return errTruncated
+			}
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				return nil
+			}
+		}
+	}
+
+	var pixels int64
+	for {
+		if pos >= len(data) {
+			return 0, errTruncated
+		}
+		switch data[pos] {
+		case 0x21: // extension: label, then sub-blocks
+			pos += 2
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+		case 0x2C: // image descriptor
+			if pos+10 > len(data) {
+				return 0, errTruncated
+			}
+			w := int64(data[pos+5]) | int64(data[pos+6])<<8
+			h := int64(data[pos+7]) | int64(data[pos+8])<<8
+			pixels += w * h
+			if pixels > MaxPixels {
+				return pixels, nil
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << ((flags & 0x07) + 1)
+			}
+			pos++ // LZW minimum code size
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+		case 0x3B: // trailer
+			return pixels, nil
+		default:
+			return 0, errors.New("gif has an unknown block")
+		}
+	}
+}
+
+// thumbnail scales img to fit within ThumbnailSize x ThumbnailSize by
+// averaging the source pixels behind each thumbnail pixel. Images that
+// already fit are returned unscaled.
+func thumbnail(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= ThumbnailSize && h <= ThumbnailSize {
+		return img
+	}
+
+	tw, th := ThumbnailSize, h*ThumbnailSize/w
+	if h > w {
+		tw, th = w*ThumbnailSize/h, ThumbnailSize
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, max((x+1)*w/tw, x*w/tw+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
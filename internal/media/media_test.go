@@ -0,0 +1,194 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying orientation right
+// after the SOI marker of a JPEG.
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("jpeg orientation is applied and exif stripped", func(t *testing.T) {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, testImage(40, 20), nil)
+		data := withOrientation(buf.Bytes(), 6)
+
+		if got := jpegOrientation(data); got != 6 {
+			t.Fatalf("Expected orientation 6, got %d", got)
+		}
+
+		img, err := Process(data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if img.ContentType != "image/jpeg" || img.Ext != ".jpg" {
+			t.Errorf("Expected image/jpeg .jpg, got %s %s", img.ContentType, img.Ext)
+		}
+		if img.Width != 20 || img.Height != 40 {
+			t.Errorf("Expected rotated 20x40, got %dx%d", img.Width, img.Height)
+		}
+		if bytes.Contains(img.Data, []byte("Exif")) {
+			t.Error("Expected EXIF to be stripped")
+		}
+	})
+
+	t.Run("png thumbnail fits the bounding box", func(t *testing.T) {
+		var buf bytes.Buffer
+		png.Encode(&buf, testImage(1000, 500))
+
+		img, err := Process(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		thumb, err := png.Decode(bytes.NewReader(img.Thumbnail))
+		if err != nil {
+			t.Fatalf("Expected thumbnail to decode, got %v", err)
+		}
+		if b := thumb.Bounds(); b.Dx() != ThumbnailSize || b.Dy() != ThumbnailSize/2 {
+			t.Errorf("Expected %dx%d thumbnail, got %dx%d", ThumbnailSize, ThumbnailSize/2, b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("gif keeps its frames", func(t *testing.T) {
+		palette := color.Palette{color.Black, color.White}
+		g := &gif.GIF{
+			Image: []*image.Paletted{
+				image.NewPaletted(image.Rect(0, 0, 10, 10), palette),
+				image.NewPaletted(image.Rect(0, 0, 10, 10), palette),
+			},
+			Delay: []int{10, 10},
+		}
+		var buf bytes.Buffer
+		gif.EncodeAll(&buf, g)
+
+		img, err := Process(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		out, err := gif.DecodeAll(bytes.NewReader(img.Data))
+		if err != nil {
+			t.Fatalf("Expected gif to decode, got %v", err)
+		}
+		if len(out.Image) != 2 {
+			t.Errorf("Expected 2 frames, got %d", len(out.Image))
+		}
+	})
+
+	t.Run("rejects gifs whose frames add up to too many pixels", func(t *testing.T) {
+		// Each frame fits on its own; together they exceed MaxPixels. The
+		// frames carry no image data, so only the block scan can catch this.
+		data := []byte("GIF89a")
+		data = binary.LittleEndian.AppendUint16(data, 4000)
+		data = binary.LittleEndian.AppendUint16(data, 4000)
+		data = append(data, 0, 0, 0)
+		for i := 0; i < 3; i++ {
+			data = append(data, 0x2C, 0, 0, 0, 0)
+			data = binary.LittleEndian.AppendUint16(data, 4000)
+			data = binary.LittleEndian.AppendUint16(data, 4000)
+			data = append(data, 0, 2, 0)
+		}
+		data = append(data, 0x3B)
+
+		_, err := Process(data)
+		if !errors.Is(err, ErrTooManyPixels) {
+			t.Errorf("Expected ErrTooManyPixels, got %v", err)
+		}
+	})
+
+	t.Run("rejects other content", func(t *testing.T) {
+		_, err := Process([]byte("<html><body>not an image</body></html>"))
+		if !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("Expected ErrUnsupportedType, got %v", err)
+		}
+	})
+
+	t.Run("rejects huge dimensions before decoding", func(t *testing.T) {
+		ihdr := binary.BigEndian.AppendUint32(nil, 100000)
+		ihdr = binary.BigEndian.AppendUint32(ihdr, 100000)
+		ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+		data := []byte("\x89PNG\r\n\x1a\n")
+		data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)))
+		chunk := append([]byte("IHDR"), ihdr...)
+		data = append(data, chunk...)
+		data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+
+		_, err := Process(data)
+		if !errors.Is(err, ErrTooManyPixels) {
+			t.Errorf("Expected ErrTooManyPixels, got %v", err)
+		}
+	})
+}
+
+func TestOrient(t *testing.T) {
+	src := testImage(3, 2)
+	topLeft := src.RGBAAt(0, 0)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		x, y        int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+
+	for _, tt := range tests {
+		out := orient(src, tt.orientation)
+		b := out.Bounds()
+		if b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: expected %dx%d, got %dx%d", tt.orientation, tt.w, tt.h, b.Dx(), b.Dy())
+			continue
+		}
+		if got := color.RGBAModel.Convert(out.At(tt.x, tt.y)); got != topLeft {
+			t.Errorf("orientation %d: expected original top-left at (%d,%d)", tt.orientation, tt.x, tt.y)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package safehttp builds HTTP clients for requests to URLs chosen by users
+// or remote servers, which must not be able to reach the server's own
+// network.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a request would connect to a loopback,
+// private, link-local or otherwise internal address.
+var ErrBlockedAddress = errors.New("connection to internal address is not allowed")
+
+// NewClient returns an HTTP client that refuses to connect to internal
+// addresses. The check runs on the address actually dialed, after DNS
+// resolution and on every redirect, so a hostname that resolves or later
+// rebinds to an internal address is caught as well.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   Control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would be dialed instead of the target and defeat the
+			// check, so environment proxy settings are ignored.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// Control is a net.Dialer Control hook that rejects internal addresses.
+func Control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if IsInternal(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
+
+// IsInternal reports whether ip is an address that must not be reached on
+// behalf of a user.
+func IsInternal(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
@@ -0,0 +1,61 @@
+package safehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsInternal(t *testing.T) {
+	cases := []struct {
+		ip       string
+		internal bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"::ffff:127.0.0.1", true},
+		{"224.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.ip, func(t *testing.T) {
+			if got := IsInternal(net.ParseIP(c.ip)); got != c.internal {
+				t.Errorf("Expected %v, got %v", c.internal, got)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("refuses loopback", func(t *testing.T) {
+		_, err := NewClient(5 * time.Second).Get(server.URL)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("Expected ErrBlockedAddress, got %v", err)
+		}
+	})
+
+	t.Run("refuses hostnames resolving to loopback", func(t *testing.T) {
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+		_, err := NewClient(5 * time.Second).Get("http://localhost:" + port)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("Expected ErrBlockedAddress, got %v", err)
+		}
+	})
+}
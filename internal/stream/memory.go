@@ -0,0 +1,38 @@
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker delivers events within a single process.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	lastID int64
+	hub    *hub
+}
+
+// NewMemoryBroker returns a broker that retains the last historySize events
+// for resume and buffers up to bufferSize events per subscriber.
+func NewMemoryBroker(historySize, bufferSize int) *MemoryBroker {
+	return &MemoryBroker{hub: newHub(historySize, bufferSize)}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	b.hub.deliver(e)
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(after int64) *Subscription {
+	return b.hub.subscribe(after)
+}
+
+func (b *MemoryBroker) Close() error {
+	b.hub.close()
+	return nil
+}
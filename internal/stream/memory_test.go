@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func publish(t *testing.T, b Broker, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := b.Publish(context.Background(), Event{Type: ChirpCreated, ChirpID: uuid.New()}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
+
+func receiveIDs(sub *Subscription, n int) []int64 {
+	var ids []int64
+	for i := 0; i < n; i++ {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, e.ID)
+		default:
+			return ids
+		}
+	}
+	return ids
+}
+
+func TestMemoryBroker(t *testing.T) {
+	t.Run("delivers new events in order", func(t *testing.T) {
+		b := NewMemoryBroker(10, 10)
+		sub := b.Subscribe(0)
+		defer sub.Close()
+
+		publish(t, b, 3)
+
+		ids := receiveIDs(sub, 3)
+		if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+			t.Errorf("Expected ids [1 2 3], got %v", ids)
+		}
+	})
+
+	t.Run("replays events after the last seen id", func(t *testing.T) {
+		b := NewMemoryBroker(10, 10)
+		publish(t, b, 5)
+
+		sub := b.Subscribe(3)
+		defer sub.Close()
+
+		ids := receiveIDs(sub, 10)
+		if len(ids) != 2 || ids[0] != 4 || ids[1] != 5 {
+			t.Errorf("Expected ids [4 5], got %v", ids)
+		}
+	})
+
+	t.Run("replay is limited to retained history", func(t *testing.T) {
+		b := NewMemoryBroker(3, 10)
+		publish(t, b, 5)
+
+		sub := b.Subscribe(1)
+		defer sub.Close()
+
+		ids := receiveIDs(sub, 10)
+		if len(ids) != 3 || ids[0] != 3 || ids[2] != 5 {
+			t.Errorf("Expected ids [3 4 5], got %v", ids)
+		}
+	})
+
+	t.Run("drops slow consumers", func(t *testing.T) {
+		b := NewMemoryBroker(0, 2)
+		sub := b.Subscribe(0)
+
+		publish(t, b, 3)
+
+		ids := receiveIDs(sub, 10)
+		if len(ids) != 2 {
+			t.Errorf("Expected 2 buffered events, got %v", ids)
+		}
+		if _, ok := <-sub.Events(); ok {
+			t.Fatal("Expected subscription to be closed")
+		}
+		if !errors.Is(sub.Err(), ErrSlowConsumer) {
+			t.Errorf("Expected ErrSlowConsumer, got %v", sub.Err())
+		}
+	})
+
+	t.Run("close ends subscriptions", func(t *testing.T) {
+		b := NewMemoryBroker(10, 10)
+		sub := b.Subscribe(0)
+
+		b.Close()
+
+		if _, ok := <-sub.Events(); ok {
+			t.Fatal("Expected subscription to be closed")
+		}
+		if !errors.Is(sub.Err(), ErrClosed) {
+			t.Errorf("Expected ErrClosed, got %v", sub.Err())
+		}
+	})
+
+	t.Run("unsubscribed consumers stop receiving", func(t *testing.T) {
+		b := NewMemoryBroker(10, 10)
+		sub := b.Subscribe(0)
+		sub.Close()
+
+		publish(t, b, 1)
+
+		if _, ok := <-sub.Events(); ok {
+			t.Fatal("Expected subscription to be closed")
+		}
+		if sub.Err() != nil {
+			t.Errorf("Expected no error, got %v", sub.Err())
+		}
+	})
+}
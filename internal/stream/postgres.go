@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres NOTIFY channel events are published on.
+const Channel = "chirpy_stream"
+
+// PostgresBroker shares events between every instance connected to the same
+// database through LISTEN/NOTIFY. Each instance keeps its own replay history,
+// so a client can resume against any of them. Events published while an
+// instance's listener is reconnecting are not seen by that instance.
+type PostgresBroker struct {
+	conn     *sql.DB
+	db       *database.Queries
+	listener *pq.Listener
+	hub      *hub
+}
+
+func NewPostgresBroker(dbURL string, conn *sql.DB, historySize, bufferSize int) (*PostgresBroker, error) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("stream: listener: %v", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	b := &PostgresBroker{
+		conn:     conn,
+		db:       database.New(conn),
+		listener: listener,
+		hub:      newHub(historySize, bufferSize),
+	}
+	go b.listen()
+
+	return b, nil
+}
+
+func (b *PostgresBroker) listen() {
+	for {
+		select {
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established.
+			if n == nil {
+				continue
+			}
+
+			var e Event
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				log.Printf("stream: invalid event payload: %v", err)
+				continue
+			}
+			b.hub.deliver(e)
+		case <-time.After(90 * time.Second):
+			go b.listener.Ping()
+		}
+	}
+}
+
+// Publish assigns the event the next ID and notifies every instance. IDs are
+// assigned and sent under one lock held until commit, which is when Postgres
+// delivers notifications, so events always arrive in ID order. Otherwise a
+// client resuming after a later ID could skip an earlier one still in flight.
+func (b *PostgresBroker) Publish(ctx context.Context, e Event) error {
+	tx, err := b.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := b.db.WithTx(tx)
+
+	if err := q.LockStreamChannel(ctx, Channel); err != nil {
+		return err
+	}
+
+	id, err := q.NextStreamEventID(ctx)
+	if err != nil {
+		return err
+	}
+	e.ID = id
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	err = q.NotifyStreamEvent(ctx, database.NotifyStreamEventParams{
+		Channel: Channel,
+		Payload: string(payload),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (b *PostgresBroker) Subscribe(after int64) *Subscription {
+	return b.hub.subscribe(after)
+}
+
+func (b *PostgresBroker) Close() error {
+	b.hub.close()
+	return b.listener.Close()
+}
@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChirpCreated        = "chirp.created"
+	ChirpUpdated        = "chirp.updated"
+	ChirpDeleted        = "chirp.deleted"
+	NotificationCreated = "notification.created"
+	MessageCreated      = "message.created"
+)
+
+// Event describes a change to a chirp, a new notification or a new direct
+// message. It carries only what subscribers need to decide whether they are
+// interested; the chirp, notification or message itself is loaded by the
+// consumer.
+type Event struct {
+	ID         int64         `json:"id"`
+	Type       string        `json:"type"`
+	ChirpID    uuid.UUID     `json:"chirp_id"`
+	AuthorID   uuid.UUID     `json:"author_id"`
+	ReplyToID  uuid.NullUUID `json:"reply_to_id"`
+	Tags       []string      `json:"tags,omitempty"`
+	Visibility string        `json:"visibility,omitempty"`
+
+	// Set on NotificationCreated and MessageCreated events only. A message
+	// to several users is published once per recipient.
+	NotificationID uuid.UUID `json:"notification_id"`
+	MessageID      uuid.UUID `json:"message_id"`
+	RecipientID    uuid.UUID `json:"recipient_id"`
+}
+
+// IsChirpEvent reports whether e is about a chirp rather than addressed to a
+// single user.
+func (e Event) IsChirpEvent() bool {
+	return e.Type != NotificationCreated && e.Type != MessageCreated
+}
+
+// Broker fans chirp events out to subscribers. Event IDs increase over time so
+// that a subscriber can resume after the last event it saw.
+type Broker interface {
+	Publish(ctx context.Context, e Event) error
+	// Subscribe starts a subscription, first replaying the retained events
+	// with an ID greater than after. Pass 0 to receive only new events.
+	Subscribe(after int64) *Subscription
+	Close() error
+}
+
+var (
+	// ErrSlowConsumer ends a subscription whose buffer filled up.
+	ErrSlowConsumer = errors.New("subscriber fell behind")
+	ErrClosed       = errors.New("broker closed")
+)
+
+type Subscription struct {
+	events chan Event
+	hub    *hub
+	err    error
+}
+
+// Events returns the channel of events. It is closed when the subscription
+// ends; Err then reports why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, nil)
+}
+
+// hub keeps a ring of recent events for replay and delivers new ones to
+// subscribers without ever blocking the publisher: a subscriber whose buffer
+// is full is dropped and can resume from its last event ID.
+type hub struct {
+	mu         sync.Mutex
+	history    []Event
+	next       int
+	full       bool
+	bufferSize int
+	subs       map[*Subscription]struct{}
+	closed     bool
+}
+
+func newHub(historySize, bufferSize int) *hub {
+	return &hub{
+		history:    make([]Event, historySize),
+		bufferSize: bufferSize,
+		subs:       map[*Subscription]struct{}{},
+	}
+}
+
+func (h *hub) deliver(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	if len(h.history) > 0 {
+		h.history[h.next] = e
+		h.next = (h.next + 1) % len(h.history)
+		if h.next == 0 {
+			h.full = true
+		}
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- e:
+		default:
+			h.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+func (h *hub) subscribe(after int64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Size the buffer so the replay always fits on top of the live buffer.
+	sub := &Subscription{
+		events: make(chan Event, h.bufferSize+len(h.history)),
+		hub:    h,
+	}
+
+	if h.closed {
+		sub.err = ErrClosed
+		close(sub.events)
+		return sub
+	}
+
+	if after > 0 {
+		for _, e := range h.retained() {
+			if e.ID > after {
+				sub.events <- e
+			}
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// retained returns the events in the ring, oldest first.
+func (h *hub) retained() []Event {
+	if !h.full {
+		return h.history[:h.next]
+	}
+	return append(append([]Event{}, h.history[h.next:]...), h.history[:h.next]...)
+}
+
+// remove ends sub. The caller must hold h.mu.
+func (h *hub) remove(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub, ErrClosed)
+	}
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"type":"chirp.created"}`)
+
+	t.Run("valid signature", func(t *testing.T) {
+		header := Sign(secret, time.Now(), body)
+		if err := Verify(header, body, secret, DefaultTolerance); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		header := Sign(secret, time.Now(), body)
+		err := Verify(header, []byte(`{"type":"chirp.deleted"}`), secret, DefaultTolerance)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		header := Sign(secret, time.Now(), body)
+		err := Verify(header, body, "whsec_other", DefaultTolerance)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("replaced timestamp", func(t *testing.T) {
+		header := Sign(secret, time.Now().Add(-time.Hour), body)
+		_, sig, _ := strings.Cut(header, ",")
+		header = "t=" + strconv.FormatInt(time.Now().Unix(), 10) + "," + sig
+
+		err := Verify(header, body, secret, DefaultTolerance)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("expired signature", func(t *testing.T) {
+		header := Sign(secret, time.Now().Add(-time.Hour), body)
+		err := Verify(header, body, secret, DefaultTolerance)
+		if !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("Expected ErrSignatureExpired, got %v", err)
+		}
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		err := Verify("garbage", body, secret, DefaultTolerance)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	b, _ := GenerateSecret()
+	if !strings.HasPrefix(a, secretPrefix) {
+		t.Errorf("Expected prefix %s, got %s", secretPrefix, a)
+	}
+	if a == b {
+		t.Error("Expected different secrets, got the same one twice")
+	}
+}
+
+func TestSend(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"type":"webhook.test"}`)
+
+	var gotEvent, gotDelivery string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(r.Header.Get(SignatureHeader), body, secret, DefaultTolerance); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotEvent = r.Header.Get(EventHeader)
+		gotDelivery = r.Header.Get(DeliveryHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	sender := NewSender(endpoint.Client(), "test")
+
+	t.Run("signed request", func(t *testing.T) {
+		err := sender.Send(context.Background(), Request{
+			URL:        endpoint.URL + "/hook",
+			Secret:     secret,
+			DeliveryID: "delivery-1",
+			Event:      "webhook.test",
+			Payload:    payload,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if gotEvent != "webhook.test" || gotDelivery != "delivery-1" {
+			t.Errorf("Expected webhook.test/delivery-1, got %s/%s", gotEvent, gotDelivery)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		err := sender.Send(context.Background(), Request{URL: endpoint.URL + "/hook", Secret: "whsec_other", Payload: payload})
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected a 401 StatusError, got %v", err)
+		}
+	})
+
+	t.Run("failing endpoint", func(t *testing.T) {
+		err := sender.Send(context.Background(), Request{URL: endpoint.URL + "/down", Secret: secret, Payload: payload})
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected a 503 StatusError, got %v", err)
+		}
+	})
+}
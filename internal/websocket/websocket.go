@@ -0,0 +1,382 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455): the opening handshake, framing, fragmentation and the close
+// handshake. Extensions and subprotocols are not supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+	CloseTryAgainLater    = 1013
+)
+
+const (
+	acceptGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxControlPayload  = 125
+	defaultReadLimit   = 1 << 20
+	closeWriteDeadline = time.Second
+)
+
+var (
+	ErrBadHandshake   = errors.New("websocket: not a valid websocket handshake")
+	ErrMessageTooBig  = errors.New("websocket: message exceeds read limit")
+	ErrProtocol       = errors.New("websocket: protocol error")
+	ErrInvalidPayload = errors.New("websocket: text message is not valid UTF-8")
+	ErrClosed         = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer with code %d %s", e.Code, e.Text)
+}
+
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	readLimit int64
+	onPong    func()
+}
+
+// Upgrade performs the opening handshake. On ErrBadHandshake nothing has been
+// written to w, so the caller can still send an HTTP error.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, ErrBadHandshake
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, ErrBadHandshake
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:      netConn,
+		br:        brw.Reader,
+		readLimit: defaultReadLimit,
+	}, nil
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept value for a client key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadLimit caps the size of a message, including all of its fragments.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// OnPong registers a function called from ReadMessage for every pong.
+func (c *Conn) OnPong(f func()) {
+	c.onPong = f
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage returns the next text or binary message, reassembling
+// fragments. Pings are answered and pongs reported through OnPong while
+// waiting. ReadMessage must not be called concurrently.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame(int64(len(message)))
+		if err != nil {
+			c.failOnError(err)
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteControl(PongMessage, payload, time.Now().Add(closeWriteDeadline)); err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case PongMessage:
+			if c.onPong != nil {
+				c.onPong()
+			}
+			continue
+
+		case CloseMessage:
+			closeErr := parseClosePayload(payload)
+			code := closeErr.Code
+			if code == CloseNoStatusReceived {
+				code = CloseNormalClosure
+			}
+			c.Close(code, "")
+			return 0, nil, closeErr
+
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				c.failOnError(ErrProtocol)
+				return 0, nil, ErrProtocol
+			}
+			messageType = opcode
+
+		case continuationFrame:
+			if messageType == 0 {
+				c.failOnError(ErrProtocol)
+				return 0, nil, ErrProtocol
+			}
+
+		default:
+			c.failOnError(ErrProtocol)
+			return 0, nil, ErrProtocol
+		}
+
+		message = append(message, payload...)
+		if !fin {
+			continue
+		}
+
+		if messageType == TextMessage && !utf8.Valid(message) {
+			c.failOnError(ErrInvalidPayload)
+			return 0, nil, ErrInvalidPayload
+		}
+		return messageType, message, nil
+	}
+}
+
+// readFrame reads a single frame. read is the size of the message assembled
+// so far, for enforcing the read limit before the payload is allocated.
+func (c *Conn) readFrame(read int64) (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, ErrProtocol
+	}
+	opcode := int(header[0] & 0x0f)
+
+	// Clients must mask every frame.
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, ErrProtocol
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			return false, 0, nil, ErrProtocol
+		}
+	}
+
+	if opcode >= CloseMessage {
+		if !fin || length > maxControlPayload {
+			return false, 0, nil, ErrProtocol
+		}
+	} else if read+length > c.readLimit {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func parseClosePayload(payload []byte) *CloseError {
+	if len(payload) < 2 {
+		return &CloseError{Code: CloseNoStatusReceived}
+	}
+	return &CloseError{
+		Code: int(binary.BigEndian.Uint16(payload)),
+		Text: string(payload[2:]),
+	}
+}
+
+// failOnError closes the connection with the status matching a read error.
+func (c *Conn) failOnError(err error) {
+	switch {
+	case errors.Is(err, ErrMessageTooBig):
+		c.Close(CloseMessageTooBig, "message too big")
+	case errors.Is(err, ErrInvalidPayload):
+		c.Close(CloseInvalidPayload, "invalid UTF-8")
+	case errors.Is(err, ErrProtocol):
+		c.Close(CloseProtocolError, "protocol error")
+	default:
+		c.conn.Close()
+	}
+}
+
+// WriteMessage sends a single unfragmented text or binary message. It is safe
+// to call concurrently with other writes.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.writeFrame(messageType, data)
+}
+
+// WriteControl sends a ping or pong frame, waiting at most until deadline.
+func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType != PingMessage && messageType != PongMessage {
+		return fmt.Errorf("websocket: invalid control message type %d", messageType)
+	}
+	if len(data) > maxControlPayload {
+		return ErrProtocol
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(deadline)
+	return c.writeFrame(messageType, data)
+}
+
+// writeFrame writes one final frame. The caller must hold writeMu.
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	if c.closeSent {
+		return ErrClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(opcode)
+	switch {
+	case len(data) < 126:
+		header[1] = byte(len(data))
+	case len(data) <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(len(data)))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(len(data)))
+	}
+
+	if _, err := c.conn.Write(append(header, data...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close sends a close frame with code and reason, unless one was already
+// sent, and closes the underlying connection. It is safe to call more than
+// once and concurrently with writes.
+func (c *Conn) Close(code int, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if !c.closeSent {
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(closeWriteDeadline))
+		c.writeFrame(CloseMessage, payload)
+		c.closeSent = true
+	}
+
+	return c.conn.Close()
+}
@@ -0,0 +1,240 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3.
+	want := "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+	if got := AcceptKey(testKey); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// dial opens a raw client connection to server and completes the handshake.
+func dial(t *testing.T, server *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := "GET / HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + testKey + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatalf("Failed to write handshake: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != AcceptKey(testKey) {
+		t.Fatalf("Expected accept key %s, got %s", AcceptKey(testKey), got)
+	}
+
+	return conn, br
+}
+
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode int, payload []byte) {
+	t.Helper()
+
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, 0x80|byte(len(payload)))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+func readServerFrame(t *testing.T, br *bufio.Reader) (int, []byte) {
+	t.Helper()
+
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("Expected server frame to be unmasked")
+	}
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+func echoServer(readLimit int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if readLimit > 0 {
+			conn.SetReadLimit(readLimit)
+		}
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+}
+
+func TestUpgrade(t *testing.T) {
+	t.Run("rejects plain requests", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestConn(t *testing.T) {
+	t.Run("echoes text messages", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		writeClientFrame(t, conn, true, TextMessage, []byte("hello"))
+
+		opcode, payload := readServerFrame(t, br)
+		if opcode != TextMessage || string(payload) != "hello" {
+			t.Errorf("Expected text \"hello\", got opcode %d %q", opcode, payload)
+		}
+	})
+
+	t.Run("echoes extended length messages", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		message := strings.Repeat("a", 300)
+		writeClientFrame(t, conn, true, TextMessage, []byte(message))
+
+		_, payload := readServerFrame(t, br)
+		if string(payload) != message {
+			t.Errorf("Expected %d byte echo, got %d bytes", len(message), len(payload))
+		}
+	})
+
+	t.Run("reassembles fragments around control frames", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		writeClientFrame(t, conn, false, TextMessage, []byte("hel"))
+		writeClientFrame(t, conn, true, PingMessage, []byte("p"))
+		writeClientFrame(t, conn, true, continuationFrame, []byte("lo"))
+
+		opcode, payload := readServerFrame(t, br)
+		if opcode != PongMessage || string(payload) != "p" {
+			t.Errorf("Expected pong \"p\", got opcode %d %q", opcode, payload)
+		}
+		opcode, payload = readServerFrame(t, br)
+		if opcode != TextMessage || string(payload) != "hello" {
+			t.Errorf("Expected text \"hello\", got opcode %d %q", opcode, payload)
+		}
+	})
+
+	t.Run("answers close with close", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		writeClientFrame(t, conn, true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseGoingAway))
+
+		opcode, payload := readServerFrame(t, br)
+		if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseGoingAway {
+			t.Errorf("Expected close %d, got opcode %d %v", CloseGoingAway, opcode, payload)
+		}
+	})
+
+	t.Run("closes on messages over the read limit", func(t *testing.T) {
+		server := echoServer(4)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		writeClientFrame(t, conn, true, TextMessage, []byte("too long"))
+
+		opcode, payload := readServerFrame(t, br)
+		if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+			t.Errorf("Expected close %d, got opcode %d %v", CloseMessageTooBig, opcode, payload)
+		}
+	})
+
+	t.Run("rejects unmasked frames", func(t *testing.T) {
+		server := echoServer(0)
+		defer server.Close()
+		conn, br := dial(t, server)
+		defer conn.Close()
+
+		conn.Write([]byte{0x80 | TextMessage, 2, 'h', 'i'})
+
+		opcode, payload := readServerFrame(t, br)
+		if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+			t.Errorf("Expected close %d, got opcode %d %v", CloseProtocolError, opcode, payload)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) likeChirpHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, true)
+}
+
+func (cfg *apiConfig) unlikeChirpHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, false)
+}
+
+func (cfg *apiConfig) setChirpLike(w http.ResponseWriter, r *http.Request, like bool) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	target, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	if like {
+		var liked int64
+		liked, err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err == nil && liked > 0 {
+			if err := cfg.notify(r.Context(), target.UserID, notificationLike, userID, chirpID); err != nil {
+				log.Printf("Failed to notify like of chirp %s: %v", chirpID, err)
+			}
+		}
+	} else {
+		err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update like")
+		return
+	}
+
+	// Re-read the chirp so the response carries the count after this change.
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirp)
+}
+
+func (cfg *apiConfig) getUserLikesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.db.GetChirpsLikedByUser(r.Context(), database.GetChirpsLikedByUserParams{
+		UserID:   userID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve liked chirps")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve liked chirps")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirps)
+}
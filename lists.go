@@ -0,0 +1,321 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxListNameLength = 50
+
+func validateListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("List name is required")
+	}
+	if utf8.RuneCountInString(name) > maxListNameLength {
+		return "", errors.New("List name must be 50 characters or less")
+	}
+	return name, nil
+}
+
+func listFromDB(id uuid.UUID, name string, memberCount int64, createdAt, updatedAt time.Time) List {
+	return List{
+		ID:          id.String(),
+		Name:        name,
+		MemberCount: memberCount,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	}
+}
+
+// ownedList loads the {listID} list if it belongs to userID, or writes an
+// error response and returns false. Other users' lists are reported as not
+// found.
+func (cfg *apiConfig) ownedList(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.GetListRow, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID format")
+		return database.GetListRow{}, false
+	}
+
+	list, err := cfg.db.GetList(r.Context(), database.GetListParams{
+		ID:     listID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "List not found")
+			return database.GetListRow{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve list")
+		return database.GetListRow{}, false
+	}
+
+	return list, true
+}
+
+func (cfg *apiConfig) createListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	name, err := validateListName(params.Name)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbList, err := cfg.db.CreateList(r.Context(), database.CreateListParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create list")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, listFromDB(dbList.ID, dbList.Name, 0, dbList.CreatedAt, dbList.UpdatedAt))
+}
+
+func (cfg *apiConfig) getListsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetListsByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve lists")
+		return
+	}
+
+	lists := make([]List, len(rows))
+	for i, row := range rows {
+		lists[i] = listFromDB(row.ID, row.Name, row.MemberCount, row.CreatedAt, row.UpdatedAt)
+	}
+
+	respondWithJson(w, http.StatusOK, lists)
+}
+
+func (cfg *apiConfig) getListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, listFromDB(list.ID, list.Name, list.MemberCount, list.CreatedAt, list.UpdatedAt))
+}
+
+func (cfg *apiConfig) renameListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	name, err := validateListName(params.Name)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbList, err := cfg.db.RenameList(r.Context(), database.RenameListParams{
+		Name:   name,
+		ID:     list.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "List not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to update list")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, listFromDB(dbList.ID, dbList.Name, list.MemberCount, dbList.CreatedAt, dbList.UpdatedAt))
+}
+
+func (cfg *apiConfig) deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID format")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteList(r.Context(), database.DeleteListParams{
+		ID:     listID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete list")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "List not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetListMembers(r.Context(), list.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve list members")
+		return
+	}
+
+	members := make([]UserRelation, len(rows))
+	for i, row := range rows {
+		members[i] = UserRelation{
+			UserID:    row.UserID.String(),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, members)
+}
+
+func (cfg *apiConfig) addListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	memberID, ok := cfg.targetUserID(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID: list.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to add list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) removeListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	err = cfg.db.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: list.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to remove list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getListTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbChirps, err := cfg.db.GetListTimeline(r.Context(), database.GetListTimelineParams{
+		ListID:          list.ID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		ViewerID:        userID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve list timeline")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve list timeline")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, ChirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor(dbChirps, pageSize),
+	})
+}
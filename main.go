@@ -5,20 +5,45 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/VMT1312/Chirpy/internal/activitypub"
 	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/safehttp"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/VMT1312/Chirpy/internal/webhook"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type parameter struct {
-	Body     string    `json:"body"`
-	Email    string    `json:"email"`
-	USERID   uuid.UUID `json:"user_id"`
-	Password string    `json:"password"`
-	Event    string    `json:"event"`
-	Data     struct {
+	Body                 string          `json:"body"`
+	Email                string          `json:"email"`
+	USERID               uuid.UUID       `json:"user_id"`
+	Password             string          `json:"password"`
+	Handle               string          `json:"handle"`
+	DisplayName          *string         `json:"display_name"`
+	Bio                  *string         `json:"bio"`
+	AvatarURL            *string         `json:"avatar_url"`
+	Location             *string         `json:"location"`
+	Event                string          `json:"event"`
+	QuoteChirpID         uuid.UUID       `json:"quote_chirp_id"`
+	ReplyToID            uuid.UUID       `json:"reply_to_id"`
+	MediaIDs             []uuid.UUID     `json:"media_ids"`
+	PublishAt            *time.Time      `json:"publish_at"`
+	Visibility           string          `json:"visibility"`
+	Name                 string          `json:"name"`
+	Poll                 *pollParameter  `json:"poll"`
+	Choices              []int32         `json:"choices"`
+	UserIDs              []uuid.UUID     `json:"user_ids"`
+	DMsFromFollowersOnly *bool           `json:"dms_from_followers_only"`
+	UpToID               uuid.UUID       `json:"up_to_id"`
+	Preferences          map[string]bool `json:"preferences"`
+	URL                  string          `json:"url"`
+	Events               []string        `json:"events"`
+	Data                 struct {
 		UserID uuid.UUID `json:"user_id"`
 	} `json:"data"`
 }
@@ -34,12 +59,57 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	var broker stream.Broker = stream.NewMemoryBroker(streamHistorySize, streamBufferSize)
+	if os.Getenv("STREAM_BROKER") == "postgres" {
+		broker, err = stream.NewPostgresBroker(dbURL, db, streamHistorySize, streamBufferSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	defer broker.Close()
+
+	blobs, err := blobStoreFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exportBlobs, err := exportStoreFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	deletionGrace, err := deletionGraceFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
 	mux := http.NewServeMux()
 	apiCfg := &apiConfig{
-		db:        dbQueries,
-		platform:  os.Getenv("PLATFORM"),
-		JWTSecret: os.Getenv("JWT_SECRET"),
-		polkaKey:  os.Getenv("POLKA_KEY"),
+		db:            dbQueries,
+		dbConn:        db,
+		platform:      os.Getenv("PLATFORM"),
+		JWTSecret:     os.Getenv("JWT_SECRET"),
+		polkaKey:      os.Getenv("POLKA_KEY"),
+		broker:        broker,
+		blobs:         blobs,
+		exportBlobs:   exportBlobs,
+		deletionGrace: deletionGrace,
+		baseURL:       baseURL,
+		apClient:      activitypub.NewClient(safehttp.NewClient(10*time.Second), "Chirpy (+"+baseURL+")"),
+		adminKey:      os.Getenv("ADMIN_API_KEY"),
+		webhooks:      webhook.NewSender(safehttp.NewClient(10*time.Second), "Chirpy-Webhooks (+"+baseURL+")"),
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := apiCfg.runImportCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
 	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir("./app")))))
@@ -54,9 +124,11 @@ func main() {
 
 	mux.HandleFunc("POST /admin/reset", apiCfg.resetUserTable)
 
-	mux.HandleFunc("POST /api/users", apiCfg.createUserHandler)
+	mux.Handle("POST /api/users", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.createUserHandler)))
+
+	mux.Handle("POST /api/chirps", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.createChirpHandler)))
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.createChirpHandler)
+	mux.HandleFunc("POST /api/chirps/import", apiCfg.importChirpsHandler)
 
 	mux.HandleFunc("POST /api/login", apiCfg.loginHandler)
 
@@ -68,8 +140,196 @@ func main() {
 
 	mux.HandleFunc("PUT /api/users", apiCfg.updateUserHandler)
 
+	mux.HandleFunc("DELETE /api/users", apiCfg.deleteUserHandler)
+
+	mux.HandleFunc("GET /api/users/{handle}", apiCfg.getProfileHandler)
+
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirpHandler)
 
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.likeChirpHandler)
+
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.unlikeChirpHandler)
+
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.getUserLikesHandler)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirps", apiCfg.rechirpHandler)
+
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirps", apiCfg.undoRechirpHandler)
+
+	mux.HandleFunc("GET /api/chirps/{chirpID}/rechirps", apiCfg.getRechirpsHandler)
+
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.followUserHandler)
+
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.unfollowUserHandler)
+
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.getFollowersHandler)
+
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.getFollowingHandler)
+
+	mux.HandleFunc("GET /api/timeline", apiCfg.getTimelineHandler)
+
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.blockUserHandler)
+
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.unblockUserHandler)
+
+	mux.HandleFunc("GET /api/me/blocks", apiCfg.getBlockedUsersHandler)
+
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.muteUserHandler)
+
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.unmuteUserHandler)
+
+	mux.HandleFunc("GET /api/me/mutes", apiCfg.getMutedUsersHandler)
+
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.updateChirpHandler)
+
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.getChirpRevisionsHandler)
+
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.getChirpsByTagHandler)
+
+	mux.HandleFunc("GET /api/trending", apiCfg.getTrendingHandler)
+
+	mux.HandleFunc("GET /api/me/mentions", apiCfg.getMyMentionsHandler)
+
+	mux.HandleFunc("GET /api/notifications", apiCfg.getNotificationsHandler)
+
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.markNotificationsReadHandler)
+
+	mux.HandleFunc("GET /api/notifications/preferences", apiCfg.getNotificationPreferencesHandler)
+
+	mux.HandleFunc("PUT /api/notifications/preferences", apiCfg.updateNotificationPreferencesHandler)
+
+	mux.HandleFunc("GET /api/stream", apiCfg.streamHandler)
+
+	mux.HandleFunc("GET /api/ws", apiCfg.websocketHandler)
+
+	mux.Handle("POST /api/media", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.uploadMediaHandler)))
+
+	mux.HandleFunc("GET /media/{key}", apiCfg.serveMediaHandler)
+
+	mux.HandleFunc("GET /feeds/{feed}", apiCfg.getGlobalFeedHandler)
+
+	mux.HandleFunc("GET /feeds/users/{feed}", apiCfg.getUserFeedHandler)
+
+	mux.HandleFunc("GET /feeds/tags/{feed}", apiCfg.getTagFeedHandler)
+
+	mux.HandleFunc("GET /.well-known/webfinger", apiCfg.webfingerHandler)
+
+	mux.HandleFunc("GET /ap/users/{userID}", apiCfg.getActorHandler)
+
+	mux.HandleFunc("GET /ap/users/{userID}/outbox", apiCfg.getOutboxHandler)
+
+	mux.HandleFunc("GET /ap/users/{userID}/followers", apiCfg.getFollowersCollectionHandler)
+
+	mux.HandleFunc("POST /ap/users/{userID}/inbox", apiCfg.inboxHandler)
+
+	mux.HandleFunc("GET /ap/chirps/{chirpID}", apiCfg.getNoteHandler)
+
+	mux.HandleFunc("POST /api/drafts", apiCfg.createDraftHandler)
+
+	mux.HandleFunc("GET /api/drafts", apiCfg.getDraftsHandler)
+
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.getDraftHandler)
+
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.updateDraftHandler)
+
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.deleteDraftHandler)
+
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.publishDraftHandler)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.bookmarkChirpHandler)
+
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.unbookmarkChirpHandler)
+
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.getBookmarksHandler)
+
+	mux.HandleFunc("POST /api/lists", apiCfg.createListHandler)
+
+	mux.HandleFunc("GET /api/lists", apiCfg.getListsHandler)
+
+	mux.HandleFunc("GET /api/lists/{listID}", apiCfg.getListHandler)
+
+	mux.HandleFunc("PUT /api/lists/{listID}", apiCfg.renameListHandler)
+
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.deleteListHandler)
+
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.getListMembersHandler)
+
+	mux.HandleFunc("POST /api/lists/{listID}/members/{userID}", apiCfg.addListMemberHandler)
+
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.removeListMemberHandler)
+
+	mux.HandleFunc("GET /api/lists/{listID}/timeline", apiCfg.getListTimelineHandler)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.pinChirpHandler)
+
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.unpinChirpHandler)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", apiCfg.votePollHandler)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.restoreChirpHandler)
+
+	mux.HandleFunc("GET /api/me/trash", apiCfg.getTrashHandler)
+
+	mux.HandleFunc("POST /api/me/export", apiCfg.createExportHandler)
+
+	mux.HandleFunc("GET /api/me/export/{exportID}", apiCfg.getExportHandler)
+
+	mux.HandleFunc("GET /api/me/export/{exportID}/download", apiCfg.downloadExportHandler)
+
+	mux.HandleFunc("POST /api/webhooks", apiCfg.createWebhookHandler)
+
+	mux.HandleFunc("GET /api/webhooks", apiCfg.getWebhooksHandler)
+
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", apiCfg.deleteWebhookHandler)
+
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/test", apiCfg.testWebhookHandler)
+
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", apiCfg.getDeadWebhookDeliveriesHandler)
+
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/deliveries/{deliveryID}/retry", apiCfg.retryWebhookDeliveryHandler)
+
+	mux.HandleFunc("POST /admin/webhooks", apiCfg.createWebhookHandler)
+
+	mux.HandleFunc("GET /admin/webhooks", apiCfg.getWebhooksHandler)
+
+	mux.HandleFunc("DELETE /admin/webhooks/{webhookID}", apiCfg.deleteWebhookHandler)
+
+	mux.HandleFunc("POST /admin/webhooks/{webhookID}/test", apiCfg.testWebhookHandler)
+
+	mux.HandleFunc("GET /admin/webhooks/{webhookID}/deliveries", apiCfg.getDeadWebhookDeliveriesHandler)
+
+	mux.HandleFunc("POST /admin/webhooks/{webhookID}/deliveries/{deliveryID}/retry", apiCfg.retryWebhookDeliveryHandler)
+
+	mux.HandleFunc("POST /api/conversations", apiCfg.createConversationHandler)
+
+	mux.HandleFunc("GET /api/conversations", apiCfg.getConversationsHandler)
+
+	mux.HandleFunc("GET /api/conversations/unread", apiCfg.getUnreadMessagesHandler)
+
+	mux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.getConversationHandler)
+
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.getMessagesHandler)
+
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.sendMessageHandler)
+
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.markConversationReadHandler)
+
+	go apiCfg.runTrendingAggregator(time.Minute)
+
+	go apiCfg.runScheduler(10 * time.Second)
+
+	go apiCfg.runTrashPurger(time.Hour)
+
+	go apiCfg.runAccountPurger(time.Hour)
+
+	go apiCfg.runExporter(30 * time.Second)
+
+	go apiCfg.runDeliveryQueue(10 * time.Second)
+
+	go apiCfg.runWebhookDispatcher(10 * time.Second)
+
+	go apiCfg.runIdempotencyKeyPurger(time.Hour)
+
 	server := &http.Server{
 		Handler: mux,
 		Addr:    ":8080",
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/VMT1312/Chirpy/internal/blobstore"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaUploadSize = 5 << 20
+	maxMediaPerChirp   = 4
+)
+
+// exportStoreFromEnv opens the store for data export archives. It must not
+// share a root with the media store, which /media serves without
+// authentication.
+func exportStoreFromEnv() (blobstore.BlobStore, error) {
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		bucket := os.Getenv("S3_EXPORT_BUCKET")
+		if bucket == "" || bucket == os.Getenv("S3_BUCKET") {
+			return nil, errors.New("S3_EXPORT_BUCKET must name a bucket other than S3_BUCKET")
+		}
+		return blobstore.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			bucket,
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		), nil
+	}
+
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = "exports"
+	}
+	return blobstore.NewFSStore(dir)
+}
+
+// blobStoreFromEnv selects the media storage backend. Files are kept on the
+// local filesystem unless MEDIA_STORE is "s3".
+func blobStoreFromEnv() (blobstore.BlobStore, error) {
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		return blobstore.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		), nil
+	}
+
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	return blobstore.NewFSStore(dir)
+}
+
+func mediaFromDB(id uuid.UUID, contentType string, width, height int32, storageKey, thumbnailKey string) Media {
+	return Media{
+		ID:           id.String(),
+		URL:          "/media/" + storageKey,
+		ThumbnailURL: "/media/" + thumbnailKey,
+		ContentType:  contentType,
+		Width:        width,
+		Height:       height,
+	}
+}
+
+func (cfg *apiConfig) uploadMediaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaUploadSize+64<<10)
+	if err := r.ParseMultipartForm(maxMediaUploadSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File exceeds 5 MB")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Expected a multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Missing file field")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaUploadSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	if len(data) > maxMediaUploadSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File exceeds 5 MB")
+		return
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported")
+		case errors.Is(err, media.ErrTooManyPixels):
+			respondWithError(w, http.StatusBadRequest, "Image dimensions are too large")
+		default:
+			respondWithError(w, http.StatusBadRequest, "Invalid image")
+		}
+		return
+	}
+
+	id := uuid.New()
+	storageKey := id.String() + img.Ext
+	thumbnailKey := id.String() + "_thumb" + img.ThumbnailExt
+
+	if err := cfg.blobs.Put(r.Context(), storageKey, img.ContentType, img.Data); err != nil {
+		log.Printf("Failed to store media %s: %v", storageKey, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to store media")
+		return
+	}
+	if err := cfg.blobs.Put(r.Context(), thumbnailKey, img.ThumbnailContentType, img.Thumbnail); err != nil {
+		log.Printf("Failed to store media %s: %v", thumbnailKey, err)
+		cfg.blobs.Delete(r.Context(), storageKey)
+		respondWithError(w, http.StatusInternalServerError, "Failed to store media")
+		return
+	}
+
+	dbMedia, err := cfg.db.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  img.ContentType,
+		SizeBytes:    int64(len(img.Data)),
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+	if err != nil {
+		cfg.blobs.Delete(r.Context(), storageKey)
+		cfg.blobs.Delete(r.Context(), thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "Failed to save media")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, mediaFromDB(
+		dbMedia.ID, dbMedia.ContentType, dbMedia.Width, dbMedia.Height, dbMedia.StorageKey, dbMedia.ThumbnailKey,
+	))
+}
+
+// validateChirpMedia checks that every ID names an upload of userID that is
+// not yet attached to a chirp.
+func (cfg *apiConfig) validateChirpMedia(r *http.Request, userID uuid.UUID, ids []uuid.UUID) (int, string) {
+	if len(ids) > maxMediaPerChirp {
+		return http.StatusBadRequest, "A chirp can have at most 4 media attachments"
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return http.StatusBadRequest, "Duplicate media ID"
+		}
+		seen[id] = true
+	}
+
+	attachable, err := cfg.db.GetAttachableMedia(r.Context(), database.GetAttachableMediaParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to retrieve media"
+	}
+	if len(attachable) != len(ids) {
+		return http.StatusBadRequest, "Media not found or already attached"
+	}
+
+	return 0, ""
+}
+
+func (cfg *apiConfig) serveMediaHandler(w http.ResponseWriter, r *http.Request) {
+	// ServeMux decodes %2F inside the wildcard, so a slash here was escaped
+	// on purpose. Uploads never contain one.
+	key := r.PathValue("key")
+	if !blobstore.ValidKey(key) || strings.Contains(key, "/") {
+		respondWithError(w, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	owner, err := cfg.db.GetMediaOwnerByKey(r.Context(), key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve media")
+		return
+	}
+
+	// Media is visible to whoever can see the chirp it is attached to.
+	// Unattached uploads, drafts and trashed chirps are only visible to the
+	// uploader.
+	viewerID := cfg.viewerID(r)
+	visible := viewerID != uuid.Nil && owner.UserID == viewerID
+	public := false
+	if owner.ChirpID.Valid {
+		dbChirp, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+			ID:       owner.ChirpID.UUID,
+			ViewerID: viewerID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve media")
+			return
+		}
+		if err == nil {
+			visible = true
+			public = dbChirp.Visibility == visibilityPublic || dbChirp.Visibility == visibilityUnlisted
+		}
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	body, info, err := cfg.blobs.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve media")
+		return
+	}
+	defer body.Close()
+
+	// Keys are never reused, so public content can be cached indefinitely.
+	// Anything else must not outlive a change of visibility in a cache.
+	w.Header().Set("Content-Type", info.ContentType)
+	if public {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if info.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		io.Copy(w, body)
+	}
+}
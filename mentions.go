@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/chirptext"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// saveChirpMentions resolves the @handles in dbChirp's body and replaces its
+// stored mentions. Handles that match no user, or a user on the other side of
+// a block from the author, are left as plain text. It returns the users who
+// were not already mentioned before this call. q may be bound to a
+// transaction.
+func saveChirpMentions(ctx context.Context, q *database.Queries, dbChirp database.Chirp) ([]uuid.UUID, error) {
+	previous, err := q.GetMentionedUserIDs(ctx, dbChirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.DeleteChirpMentions(ctx, dbChirp.ID); err != nil {
+		return nil, err
+	}
+
+	mentions := chirptext.ExtractMentions(dbChirp.Body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]string, len(mentions))
+	for i, mention := range mentions {
+		handles[i] = mention.Handle
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := map[string]uuid.UUID{}
+	for _, user := range users {
+		blocked, err := q.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+			UserA: dbChirp.UserID,
+			UserB: user.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !blocked {
+			userIDs[chirptext.NormalizeHandle(user.Handle.String)] = user.ID
+		}
+	}
+
+	arg := database.InsertChirpMentionsParams{ChirpID: dbChirp.ID}
+	for _, mention := range mentions {
+		userID, ok := userIDs[mention.Handle]
+		if !ok {
+			continue
+		}
+		arg.UserIds = append(arg.UserIds, userID)
+		arg.StartOffsets = append(arg.StartOffsets, int32(mention.Start))
+		arg.EndOffsets = append(arg.EndOffsets, int32(mention.End))
+	}
+	if len(arg.UserIds) == 0 {
+		return nil, nil
+	}
+
+	if err := q.InsertChirpMentions(ctx, arg); err != nil {
+		return nil, err
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, userID := range previous {
+		seen[userID] = true
+	}
+
+	var added []uuid.UUID
+	for _, userID := range arg.UserIds {
+		if !seen[userID] {
+			seen[userID] = true
+			added = append(added, userID)
+		}
+	}
+
+	return added, nil
+}
+
+func (cfg *apiConfig) notifyMentioned(ctx context.Context, dbChirp database.Chirp, userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		visible, err := cfg.canSeeChirp(ctx, userID, dbChirp.UserID, dbChirp.Visibility)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+		if err := cfg.notify(ctx, userID, notificationMention, dbChirp.UserID, dbChirp.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) getMyMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbChirps, err := cfg.db.GetMentionsOfUser(r.Context(), database.GetMentionsOfUserParams{
+		UserID:          userID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve mentions")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve mentions")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, ChirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor(dbChirps, pageSize),
+	})
+}
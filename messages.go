@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/VMT1312/Chirpy/internal/cursor"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	maxConversationMembers = 10
+	maxMessageLength       = 1000
+)
+
+func messageFromDB(m database.Message) Message {
+	message := Message{
+		ID:             m.ID.String(),
+		ConversationID: m.ConversationID.String(),
+		Body:           m.Body,
+		CreatedAt:      m.CreatedAt,
+	}
+	if m.SenderID.Valid {
+		senderID := m.SenderID.UUID.String()
+		message.SenderID = &senderID
+	}
+	return message
+}
+
+// directKey identifies the one-to-one conversation between two users.
+func directKey(a, b uuid.UUID) string {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return a.String() + ":" + b.String()
+}
+
+// canMessage reports whether senderID may send direct messages to
+// recipientID: neither may have blocked the other, and a recipient who only
+// accepts messages from followers must follow the sender.
+func (cfg *apiConfig) canMessage(ctx context.Context, senderID, recipientID uuid.UUID) (bool, error) {
+	blocked, err := cfg.db.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+		UserA: senderID,
+		UserB: recipientID,
+	})
+	if err != nil || blocked {
+		return false, err
+	}
+
+	recipient, err := cfg.db.GetUserByID(ctx, recipientID)
+	if err != nil {
+		return false, err
+	}
+	if !recipient.DmsFromFollowersOnly {
+		return true, nil
+	}
+
+	return cfg.db.IsFollowing(ctx, database.IsFollowingParams{
+		FollowerID: recipientID,
+		FolloweeID: senderID,
+	})
+}
+
+// buildConversations converts conversation rows into API conversations with
+// their members and read receipts.
+func (cfg *apiConfig) buildConversations(ctx context.Context, rows []database.GetConversationsForUserRow) ([]Conversation, error) {
+	conversations := make([]Conversation, len(rows))
+	ids := make([]uuid.UUID, len(rows))
+	index := make(map[uuid.UUID]int, len(rows))
+	for i, row := range rows {
+		conversations[i] = Conversation{
+			ID:          row.ID.String(),
+			Members:     []ConversationMember{},
+			UnreadCount: row.UnreadCount,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		}
+		ids[i] = row.ID
+		index[row.ID] = i
+	}
+
+	if len(rows) == 0 {
+		return conversations, nil
+	}
+
+	members, err := cfg.db.GetConversationMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		member := ConversationMember{UserID: m.UserID.String()}
+		if m.LastReadAt.Valid {
+			member.LastReadAt = &m.LastReadAt.Time
+		}
+		i := index[m.ConversationID]
+		conversations[i].Members = append(conversations[i].Members, member)
+	}
+
+	return conversations, nil
+}
+
+// memberConversation loads the {conversationID} conversation if userID is a
+// member, or writes an error response and returns false.
+func (cfg *apiConfig) memberConversation(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.GetConversationsForUserRow, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID format")
+		return database.GetConversationsForUserRow{}, false
+	}
+
+	row, err := cfg.db.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Conversation not found")
+			return database.GetConversationsForUserRow{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversation")
+		return database.GetConversationsForUserRow{}, false
+	}
+
+	return database.GetConversationsForUserRow(row), true
+}
+
+func (cfg *apiConfig) respondWithConversation(w http.ResponseWriter, r *http.Request, code int, row database.GetConversationsForUserRow) {
+	conversations, err := cfg.buildConversations(r.Context(), []database.GetConversationsForUserRow{row})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversation")
+		return
+	}
+
+	respondWithJson(w, code, conversations[0])
+}
+
+func (cfg *apiConfig) createConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	seen := map[uuid.UUID]bool{userID: true}
+	var others []uuid.UUID
+	for _, id := range params.UserIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		respondWithError(w, http.StatusBadRequest, "A conversation needs at least one other member")
+		return
+	}
+	if len(others)+1 > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, "A conversation can have at most 10 members")
+		return
+	}
+
+	for _, id := range others {
+		allowed, err := cfg.canMessage(r.Context(), userID, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to check messaging permissions")
+			return
+		}
+		if !allowed {
+			respondWithError(w, http.StatusForbidden, "You cannot message this user")
+			return
+		}
+	}
+
+	var key sql.NullString
+	if len(others) == 1 {
+		key = sql.NullString{String: directKey(userID, others[0]), Valid: true}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	status := http.StatusCreated
+	conversation, err := q.CreateConversation(r.Context(), key)
+	if err == sql.ErrNoRows {
+		// The two users already have a conversation: return it.
+		status = http.StatusOK
+		conversation, err = q.GetConversationByDirectKey(r.Context(), key)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
+		return
+	}
+
+	if status == http.StatusCreated {
+		err = q.AddConversationMembers(r.Context(), database.AddConversationMembersParams{
+			ConversationID: conversation.ID,
+			UserIds:        append([]uuid.UUID{userID}, others...),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
+		return
+	}
+
+	row, err := cfg.db.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ID:     conversation.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversation")
+		return
+	}
+
+	cfg.respondWithConversation(w, r, status, database.GetConversationsForUserRow(row))
+}
+
+func (cfg *apiConfig) getConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetConversationsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversations")
+		return
+	}
+
+	conversations, err := cfg.buildConversations(r.Context(), rows)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversations")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, conversations)
+}
+
+func (cfg *apiConfig) getConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	row, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	cfg.respondWithConversation(w, r, http.StatusOK, row)
+}
+
+func (cfg *apiConfig) getMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbMessages, err := cfg.db.GetMessages(r.Context(), database.GetMessagesParams{
+		ConversationID:  conversation.ID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve messages")
+		return
+	}
+
+	page := MessagePage{Messages: make([]Message, len(dbMessages))}
+	for i, m := range dbMessages {
+		page.Messages[i] = messageFromDB(m)
+	}
+	if len(dbMessages) == int(pageSize) {
+		last := dbMessages[len(dbMessages)-1]
+		page.NextCursor = cursor.Encode(cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if strings.TrimSpace(params.Body) == "" {
+		respondWithError(w, http.StatusBadRequest, "Message cannot be empty")
+		return
+	}
+	if utf8.RuneCountInString(params.Body) > maxMessageLength {
+		respondWithError(w, http.StatusBadRequest, "Message exceeds 1000 characters")
+		return
+	}
+
+	members, err := cfg.db.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve conversation")
+		return
+	}
+
+	// Blocks and messaging preferences may have changed since the
+	// conversation started, so they are checked on every message.
+	for _, m := range members {
+		if m.UserID == userID {
+			continue
+		}
+		allowed, err := cfg.canMessage(r.Context(), userID, m.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to check messaging permissions")
+			return
+		}
+		if !allowed {
+			respondWithError(w, http.StatusForbidden, "You cannot message one or more members of this conversation")
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	dbMessage, err := q.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversation.ID,
+		SenderID:       uuid.NullUUID{UUID: userID, Valid: true},
+		Body:           params.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+
+	err = q.TouchConversation(r.Context(), database.TouchConversationParams{
+		UpdatedAt: dbMessage.CreatedAt,
+		ID:        conversation.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+
+	// Every member is told, including the sender's other connections.
+	for _, m := range members {
+		e := stream.Event{
+			Type:        stream.MessageCreated,
+			MessageID:   dbMessage.ID,
+			RecipientID: m.UserID,
+		}
+		if err := cfg.broker.Publish(r.Context(), e); err != nil {
+			log.Printf("Failed to publish message %s: %v", dbMessage.ID, err)
+		}
+	}
+
+	respondWithJson(w, http.StatusCreated, messageFromDB(dbMessage))
+}
+
+func (cfg *apiConfig) markConversationReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	// Without up_to_id, everything up to the latest message is read.
+	var upTo database.Message
+	if params.UpToID != uuid.Nil {
+		var err error
+		upTo, err = cfg.db.GetMessageForMember(r.Context(), database.GetMessageForMemberParams{
+			ID:     params.UpToID,
+			UserID: userID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve message")
+			return
+		}
+		if err == sql.ErrNoRows || upTo.ConversationID != conversation.ID {
+			respondWithError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+	} else {
+		start := cursor.Start()
+		latest, err := cfg.db.GetMessages(r.Context(), database.GetMessagesParams{
+			ConversationID:  conversation.ID,
+			BeforeCreatedAt: start.CreatedAt,
+			BeforeID:        start.ID,
+			PageSize:        1,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve messages")
+			return
+		}
+		if len(latest) > 0 {
+			upTo = latest[0]
+		}
+	}
+
+	if upTo.ID != uuid.Nil {
+		err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+			ReadAt:         upTo.CreatedAt,
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to mark conversation read")
+			return
+		}
+	}
+
+	cfg.respondWithUnreadMessages(w, r, userID)
+}
+
+func (cfg *apiConfig) getUnreadMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	cfg.respondWithUnreadMessages(w, r, userID)
+}
+
+func (cfg *apiConfig) respondWithUnreadMessages(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	unread, err := cfg.db.CountUnreadMessages(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to count unread messages")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, UnreadCount{UnreadCount: unread})
+}
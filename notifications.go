@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/cursor"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationLike       = "like"
+	notificationReply      = "reply"
+	notificationMention    = "mention"
+	notificationFollow     = "follow"
+	notificationModeration = "moderation"
+	notificationChirpyRed  = "chirpy_red"
+)
+
+var notificationTypes = []string{
+	notificationLike,
+	notificationReply,
+	notificationMention,
+	notificationFollow,
+	notificationModeration,
+	notificationChirpyRed,
+}
+
+// notify records a notification for userID about something actorID did. Pass
+// uuid.Nil as actorID for system events. Users are never notified about their
+// own actions, and the insert is skipped when the user has disabled the type
+// or muted the actor.
+func (cfg *apiConfig) notify(ctx context.Context, userID uuid.UUID, kind string, actorID uuid.UUID, chirpID uuid.UUID) error {
+	if userID == actorID {
+		return nil
+	}
+
+	id, err := cfg.db.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  userID,
+		Type:    kind,
+		ActorID: uuid.NullUUID{UUID: actorID, Valid: actorID != uuid.Nil},
+		ChirpID: uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	e := stream.Event{
+		Type:           stream.NotificationCreated,
+		NotificationID: id,
+		RecipientID:    userID,
+	}
+	if err := cfg.broker.Publish(ctx, e); err != nil {
+		log.Printf("Failed to publish notification %s: %v", id, err)
+	}
+
+	return nil
+}
+
+func notificationFromDB(n database.GetNotificationsRow) Notification {
+	notification := Notification{
+		ID:          n.ID.String(),
+		CreatedAt:   n.CreatedAt,
+		Type:        n.Type,
+		ActorHandle: n.ActorHandle.String,
+		Read:        n.ReadAt.Valid,
+	}
+	if n.ActorID.Valid {
+		actorID := n.ActorID.UUID.String()
+		notification.ActorID = &actorID
+	}
+	if n.ChirpID.Valid {
+		chirpID := n.ChirpID.UUID.String()
+		notification.ChirpID = &chirpID
+	}
+	return notification
+}
+
+// notifyCensored tells the author when banned words were removed from the body
+// they submitted.
+func (cfg *apiConfig) notifyCensored(ctx context.Context, dbChirp database.Chirp, submitted string) error {
+	if dbChirp.Body == submitted {
+		return nil
+	}
+
+	return cfg.notify(ctx, dbChirp.UserID, notificationModeration, uuid.Nil, dbChirp.ID)
+}
+
+func (cfg *apiConfig) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbNotifications, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve notifications")
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve notifications")
+		return
+	}
+
+	page := NotificationPage{
+		Notifications: []Notification{},
+		UnreadCount:   unread,
+	}
+	for _, n := range dbNotifications {
+		page.Notifications = append(page.Notifications, notificationFromDB(n))
+	}
+
+	if len(dbNotifications) == int(pageSize) {
+		last := dbNotifications[len(dbNotifications)-1]
+		page.NextCursor = cursor.Encode(cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) markNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if params.UpToID == uuid.Nil {
+		respondWithError(w, http.StatusBadRequest, "up_to_id is required")
+		return
+	}
+
+	_, err := cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+		UserID: userID,
+		UpToID: params.UpToID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to mark notifications as read")
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve notifications")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, UnreadCount{UnreadCount: unread})
+}
+
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	dbPreferences, err := cfg.db.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]bool, len(notificationTypes))
+	for _, kind := range notificationTypes {
+		preferences[kind] = true
+	}
+	for _, p := range dbPreferences {
+		preferences[p.Type] = p.Enabled
+	}
+
+	return preferences, nil
+}
+
+func (cfg *apiConfig) getNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve notification preferences")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, NotificationPreferences{Preferences: preferences})
+}
+
+func (cfg *apiConfig) updateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	valid := make(map[string]bool, len(notificationTypes))
+	for _, kind := range notificationTypes {
+		valid[kind] = true
+	}
+	for kind := range params.Preferences {
+		if !valid[kind] {
+			respondWithError(w, http.StatusBadRequest, "Unknown notification type: "+kind)
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update notification preferences")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	for kind, enabled := range params.Preferences {
+		err := q.SetNotificationPreference(r.Context(), database.SetNotificationPreferenceParams{
+			UserID:  userID,
+			Type:    kind,
+			Enabled: enabled,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update notification preferences")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update notification preferences")
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve notification preferences")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, NotificationPreferences{Preferences: preferences})
+}
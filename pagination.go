@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/VMT1312/Chirpy/internal/cursor"
+	"github.com/VMT1312/Chirpy/internal/database"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func parsePage(r *http.Request) (cursor.Cursor, int32, error) {
+	c, err := cursor.Decode(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return cursor.Cursor{}, 0, err
+	}
+
+	pageSize := defaultPageSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		pageSize, err = strconv.Atoi(s)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return cursor.Cursor{}, 0, errors.New("limit must be between 1 and 100")
+		}
+	}
+
+	return c, int32(pageSize), nil
+}
+
+// nextCursor returns the cursor for the page after dbChirps, or "" when the
+// page was not full and there is nothing more to fetch.
+func nextCursor(dbChirps []database.Chirp, pageSize int32) string {
+	if len(dbChirps) < int(pageSize) {
+		return ""
+	}
+
+	last := dbChirps[len(dbChirps)-1]
+	return cursor.Encode(cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPinnedChirps          = 1
+	maxPinnedChirpsChirpyRed = 5
+)
+
+func pinLimit(chirpyRed bool) int64 {
+	if chirpyRed {
+		return maxPinnedChirpsChirpyRed
+	}
+	return maxPinnedChirps
+}
+
+// pinnedFirst moves the pinned chirps to the front of chirps, most recently
+// pinned first, keeping the order of the rest.
+func pinnedFirst(chirps []Chirp, dbChirps []database.Chirp) {
+	pinnedAt := make(map[string]int64, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		if dbChirp.PinnedAt.Valid {
+			pinnedAt[dbChirp.ID.String()] = dbChirp.PinnedAt.Time.UnixNano()
+		}
+	}
+
+	sort.SliceStable(chirps, func(i, j int) bool {
+		pi, iPinned := pinnedAt[chirps[i].ID]
+		pj, jPinned := pinnedAt[chirps[j].ID]
+		if iPinned != jPinned {
+			return iPinned
+		}
+		return iPinned && pi > pj
+	})
+}
+
+func (cfg *apiConfig) pinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to pin chirp")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	// Locking the user serializes concurrent pins so the limit holds.
+	dbUser, err := q.GetUserByIDForUpdate(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	dbChirp, err := q.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+	if dbChirp.UserID != userID {
+		visible, err := cfg.canSeeDBChirp(r.Context(), userID, dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusForbidden, "You can only pin your own chirps")
+		return
+	}
+	if dbChirp.Status != "published" {
+		respondWithError(w, http.StatusBadRequest, "Drafts cannot be pinned")
+		return
+	}
+	if dbChirp.Kind == "rechirp" {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be pinned")
+		return
+	}
+	if dbChirp.PinnedAt.Valid {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	pinned, err := q.CountPinnedChirps(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to pin chirp")
+		return
+	}
+	if limit := pinLimit(dbUser.ChirpyRed); pinned >= limit {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("You can pin at most %d chirps; unpin one first", limit))
+		return
+	}
+
+	if err := q.PinChirp(r.Context(), chirpID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to pin chirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to pin chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unpinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	err = cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to unpin chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
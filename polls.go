@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+type pollParameter struct {
+	Options  []string  `json:"options"`
+	Multiple bool      `json:"multiple"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// validatePoll checks a poll submitted with a new chirp and returns its
+// options, trimmed and censored like a chirp body.
+func validatePoll(p *pollParameter) ([]string, error) {
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		return nil, errors.New("A poll must have between 2 and 4 options")
+	}
+
+	options := make([]string, len(p.Options))
+	seen := map[string]bool{}
+	for i, option := range p.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, errors.New("Poll options cannot be empty")
+		}
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return nil, fmt.Errorf("Poll options must be %d characters or less", maxPollOptionLength)
+		}
+		if seen[strings.ToLower(option)] {
+			return nil, errors.New("Poll options must be unique")
+		}
+		seen[strings.ToLower(option)] = true
+
+		cleaned, err := cleanChirpBody(option)
+		if err != nil {
+			return nil, err
+		}
+		options[i] = cleaned
+	}
+
+	untilClose := time.Until(p.ClosesAt)
+	if untilClose < minPollDuration || untilClose > maxPollDuration {
+		return nil, errors.New("closes_at must be between 5 minutes and 7 days from now")
+	}
+
+	return options, nil
+}
+
+// validatePollChoices checks the option positions of a vote.
+func validatePollChoices(choices []int32, poll database.GetPollRow) error {
+	if len(choices) == 0 {
+		return errors.New("Choose at least one option")
+	}
+	if !poll.Multiple && len(choices) > 1 {
+		return errors.New("This poll allows a single choice")
+	}
+
+	seen := map[int32]bool{}
+	for _, choice := range choices {
+		if choice < 0 || int64(choice) >= poll.OptionCount {
+			return errors.New("Invalid poll option")
+		}
+		if seen[choice] {
+			return errors.New("Each option can only be chosen once")
+		}
+		seen[choice] = true
+	}
+
+	return nil
+}
+
+// loadPolls returns the polls attached to chirpIDs as seen by viewerID. Vote
+// counts stay hidden until the viewer has voted or the poll has closed. Each
+// poll's counts come from a single query, so they are consistent with each
+// other even while votes are coming in.
+func (cfg *apiConfig) loadPolls(ctx context.Context, viewerID uuid.UUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	rows, err := cfg.db.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	polls := map[uuid.UUID]*Poll{}
+	if len(rows) == 0 {
+		return polls, nil
+	}
+
+	voted := map[uuid.UUID][]int32{}
+	if viewerID != uuid.Nil {
+		votes, err := cfg.db.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			voted[vote.ChirpID] = append(voted[vote.ChirpID], vote.Position)
+		}
+	}
+
+	for _, row := range rows {
+		poll, ok := polls[row.ChirpID]
+		if !ok {
+			ownVotes, hasVoted := voted[row.ChirpID]
+			poll = &Poll{
+				Options:  []PollOption{},
+				Multiple: row.Multiple,
+				ClosesAt: row.ClosesAt,
+				Closed:   row.Closed,
+				Voted:    hasVoted,
+				OwnVotes: ownVotes,
+			}
+			if hasVoted || row.Closed {
+				voterCount := row.VoterCount
+				poll.VoterCount = &voterCount
+			}
+			polls[row.ChirpID] = poll
+		}
+
+		option := PollOption{Text: row.Text}
+		if poll.VoterCount != nil {
+			voteCount := row.VoteCount
+			option.Votes = &voteCount
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	return polls, nil
+}
+
+func (cfg *apiConfig) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	poll, err := cfg.db.GetPoll(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp has no poll")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve poll")
+		return
+	}
+
+	params := parameter{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := validatePollChoices(params.Choices, poll); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to record vote")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	added, err := q.AddPollVoter(r.Context(), database.AddPollVoterParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to record vote")
+		return
+	}
+	if added == 0 {
+		if !poll.ClosesAt.After(time.Now()) {
+			respondWithError(w, http.StatusConflict, "Poll is closed")
+			return
+		}
+		respondWithError(w, http.StatusConflict, "You have already voted in this poll")
+		return
+	}
+
+	err = q.InsertPollVotes(r.Context(), database.InsertPollVotesParams{
+		ChirpID:   chirpID,
+		UserID:    userID,
+		Positions: params.Choices,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to record vote")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to record vote")
+		return
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirp)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/VMT1312/Chirpy/internal/chirptext"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxAvatarURLLength   = 2048
+)
+
+func userFromDB(dbUser database.User) User {
+	return User{
+		ID:                   dbUser.ID,
+		CreatedAt:            dbUser.CreatedAt,
+		UpdatedAt:            dbUser.UpdatedAt,
+		Email:                dbUser.Email,
+		Handle:               dbUser.Handle.String,
+		DisplayName:          dbUser.DisplayName,
+		Bio:                  dbUser.Bio,
+		AvatarURL:            dbUser.AvatarUrl,
+		Location:             dbUser.Location,
+		IsChirpyRed:          dbUser.ChirpyRed,
+		DMsFromFollowersOnly: dbUser.DmsFromFollowersOnly,
+	}
+}
+
+func optionalString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func optionalBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+// validateProfile checks the profile fields of an update request. Fields that
+// are absent from the request are left untouched and are not validated.
+func validateProfile(params parameter) error {
+	if params.Handle != "" && !chirptext.ValidHandle(params.Handle) {
+		return errors.New("Handle must be 3-30 letters, digits or underscores")
+	}
+	if params.DisplayName != nil && utf8.RuneCountInString(*params.DisplayName) > maxDisplayNameLength {
+		return errors.New("Display name exceeds 50 characters")
+	}
+	if params.Bio != nil && utf8.RuneCountInString(*params.Bio) > maxBioLength {
+		return errors.New("Bio exceeds 160 characters")
+	}
+	if params.Location != nil && utf8.RuneCountInString(*params.Location) > maxLocationLength {
+		return errors.New("Location exceeds 30 characters")
+	}
+	if params.AvatarURL != nil && *params.AvatarURL != "" {
+		if len(*params.AvatarURL) > maxAvatarURLLength {
+			return errors.New("Avatar URL is too long")
+		}
+		u, err := url.Parse(*params.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("Avatar URL must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	handle := r.PathValue("handle")
+	if !chirptext.ValidHandle(handle) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	dbProfile, err := cfg.db.GetPublicProfileByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	if viewerID != uuid.Nil {
+		blocked, err := cfg.db.IsBlockedEitherWay(r.Context(), database.IsBlockedEitherWayParams{
+			UserA: viewerID,
+			UserB: dbProfile.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+	}
+
+	profile := Profile{
+		ID:             dbProfile.ID,
+		CreatedAt:      dbProfile.CreatedAt,
+		Handle:         dbProfile.Handle.String,
+		DisplayName:    dbProfile.DisplayName,
+		Bio:            dbProfile.Bio,
+		AvatarURL:      dbProfile.AvatarUrl,
+		Location:       dbProfile.Location,
+		IsChirpyRed:    dbProfile.ChirpyRed,
+		ChirpCount:     dbProfile.ChirpCount,
+		FollowerCount:  dbProfile.FollowerCount,
+		FollowingCount: dbProfile.FollowingCount,
+	}
+
+	dbPinned, err := cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+		UserID:   dbProfile.ID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve pinned chirps")
+		return
+	}
+	profile.PinnedChirps, err = cfg.buildChirps(r.Context(), viewerID, dbPinned)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve pinned chirps")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, profile)
+}
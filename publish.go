@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// indexChirp stores the tags, mentions and timeline entries of a newly
+// published chirp and returns the users to notify of a mention. q may be bound
+// to a transaction.
+func indexChirp(ctx context.Context, q *database.Queries, dbChirp database.Chirp) ([]uuid.UUID, error) {
+	if err := saveChirpTags(ctx, q, dbChirp); err != nil {
+		return nil, err
+	}
+
+	mentioned, err := saveChirpMentions(ctx, q, dbChirp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.FanOutChirp(ctx, dbChirp.ID); err != nil {
+		return nil, err
+	}
+
+	return mentioned, nil
+}
+
+// announceChirp tells live subscribers and affected users about a chirp that
+// indexChirp has stored.
+func (cfg *apiConfig) announceChirp(ctx context.Context, dbChirp database.Chirp, mentioned []uuid.UUID) error {
+	cfg.publishStreamEvent(ctx, stream.ChirpCreated, dbChirp)
+
+	if err := cfg.notifyMentioned(ctx, dbChirp, mentioned); err != nil {
+		return err
+	}
+
+	if dbChirp.ReplyToID.Valid {
+		parent, err := cfg.db.GetChirpByID(ctx, dbChirp.ReplyToID.UUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		visible, err := cfg.canSeeChirp(ctx, parent.UserID, dbChirp.UserID, dbChirp.Visibility)
+		if err != nil || !visible {
+			return err
+		}
+		return cfg.notify(ctx, parent.UserID, notificationReply, dbChirp.UserID, dbChirp.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// resolveOriginal returns the chirp that a rechirp or quote of chirpID by
+// userID should point at. Rechirping a rechirp shares the underlying original
+// instead. Chirps across a block are reported as sql.ErrNoRows.
+func (cfg *apiConfig) resolveOriginal(r *http.Request, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	dbChirp, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if dbChirp.Kind == "rechirp" && dbChirp.OriginalChirpID.Valid {
+		return cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+			ID:       dbChirp.OriginalChirpID.UUID,
+			ViewerID: userID,
+		})
+	}
+
+	return dbChirp, nil
+}
+
+func (cfg *apiConfig) rechirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	original, err := cfg.resolveOriginal(r, userID, chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	// A rechirp would show the chirp to the rechirper's audience.
+	if original.Visibility == visibilityFollowers || original.Visibility == visibilityPrivate {
+		respondWithError(w, http.StatusForbidden, "Only public and unlisted chirps can be rechirped")
+		return
+	}
+
+	arg := database.CreateRechirpParams{
+		UserID:          userID,
+		OriginalChirpID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create rechirp")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	status := http.StatusCreated
+	var mentioned []uuid.UUID
+	dbChirp, err := q.CreateRechirp(r.Context(), arg)
+	if err == sql.ErrNoRows {
+		// Already rechirped: return the existing rechirp.
+		status = http.StatusOK
+		dbChirp, err = q.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:          arg.UserID,
+			OriginalChirpID: arg.OriginalChirpID,
+		})
+	} else if err == nil {
+		mentioned, err = indexChirp(r.Context(), q, dbChirp)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create rechirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create rechirp")
+		return
+	}
+
+	if status == http.StatusCreated {
+		if err := cfg.announceChirp(r.Context(), dbChirp, mentioned); err != nil {
+			log.Printf("Failed to announce chirp %s: %v", dbChirp.ID, err)
+		}
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load chirp")
+		return
+	}
+
+	respondWithJson(w, status, chirp)
+}
+
+func (cfg *apiConfig) undoRechirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	rechirp, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:          userID,
+		OriginalChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Rechirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete rechirp")
+		return
+	}
+	cfg.publishStreamEvent(r.Context(), stream.ChirpDeleted, rechirp)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getRechirpsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+	if dbChirp.UserID != userID {
+		visible, err := cfg.canSeeDBChirp(r.Context(), userID, dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		respondWithError(w, http.StatusForbidden, "Only the author can see who rechirped this chirp")
+		return
+	}
+
+	rows, err := cfg.db.GetRechirpsOfChirp(r.Context(), uuid.NullUUID{UUID: chirpID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve rechirps")
+		return
+	}
+
+	rechirps := make([]Rechirp, len(rows))
+	for i, row := range rows {
+		rechirps[i] = Rechirp{
+			UserID:      row.UserID.String(),
+			RechirpedAt: row.CreatedAt,
+		}
+	}
+
+	respondWithJson(w, http.StatusOK, rechirps)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) authenticatedUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
+		return uuid.Nil, false
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.JWTSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+		return uuid.Nil, false
+	}
+
+	// Tokens stay valid until they expire, so accounts pending deletion are
+	// checked on every request.
+	active, err := cfg.db.IsActiveUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return uuid.Nil, false
+	}
+	if !active {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token: account is deleted or pending deletion")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// viewerID returns the caller's user ID for endpoints where authentication is
+// optional, or uuid.Nil when the request is anonymous or the token is invalid.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.UUID {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	active, err := cfg.db.IsActiveUser(r.Context(), userID)
+	if err != nil || !active {
+		return uuid.Nil
+	}
+
+	return userID
+}
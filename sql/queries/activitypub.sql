@@ -0,0 +1,80 @@
+-- name: SetUserActorKeys :execrows
+UPDATE users
+SET ap_private_key = $2, ap_public_key = $3
+WHERE id = $1 AND ap_private_key IS NULL;
+
+-- name: GetRemoteActorByKeyID :one
+SELECT * FROM remote_actors
+WHERE key_id = sqlc.arg(key_id)
+AND fetched_at > NOW() - make_interval(secs => sqlc.arg(max_age_seconds)::int);
+
+-- name: GetRemoteActor :one
+SELECT * FROM remote_actors
+WHERE actor_url = $1;
+
+-- name: UpsertRemoteActor :exec
+INSERT INTO remote_actors (actor_url, inbox_url, shared_inbox_url, key_id, public_key_pem, fetched_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (actor_url) DO UPDATE
+SET inbox_url = EXCLUDED.inbox_url,
+    shared_inbox_url = EXCLUDED.shared_inbox_url,
+    key_id = EXCLUDED.key_id,
+    public_key_pem = EXCLUDED.public_key_pem,
+    fetched_at = EXCLUDED.fetched_at;
+
+-- name: DeleteRemoteActor :exec
+DELETE FROM remote_actors
+WHERE actor_url = $1;
+
+-- name: AddRemoteFollower :exec
+INSERT INTO remote_followers (user_id, actor_url, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveRemoteFollower :exec
+DELETE FROM remote_followers
+WHERE user_id = $1 AND actor_url = $2;
+
+-- name: CountRemoteFollowers :one
+SELECT COUNT(*) FROM remote_followers
+WHERE user_id = $1;
+
+-- name: EnqueueDelivery :exec
+INSERT INTO ap_deliveries (id, user_id, inbox_url, activity, next_attempt_at, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, NOW(), NOW());
+
+-- name: EnqueueFollowerDeliveries :exec
+INSERT INTO ap_deliveries (id, user_id, inbox_url, activity, next_attempt_at, created_at)
+SELECT gen_random_uuid(), sqlc.arg(user_id)::uuid, inboxes.inbox_url, sqlc.arg(activity)::text, NOW(), NOW()
+FROM (
+    SELECT DISTINCT COALESCE(remote_actors.shared_inbox_url, remote_actors.inbox_url) AS inbox_url
+    FROM remote_followers
+    JOIN remote_actors ON remote_actors.actor_url = remote_followers.actor_url
+    WHERE remote_followers.user_id = sqlc.arg(user_id)::uuid
+) AS inboxes;
+
+-- name: ClaimDueDeliveries :many
+UPDATE ap_deliveries
+SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT due.id FROM ap_deliveries AS due
+    WHERE due.status = 'pending' AND due.next_attempt_at <= NOW()
+    ORDER BY due.next_attempt_at
+    LIMIT sqlc.arg(max_deliveries)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDelivery :exec
+DELETE FROM ap_deliveries
+WHERE id = $1;
+
+-- name: RetryDelivery :exec
+UPDATE ap_deliveries
+SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg(delay_seconds)::int), last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: FailDelivery :exec
+UPDATE ap_deliveries
+SET status = 'failed', last_error = $2
+WHERE id = $1;
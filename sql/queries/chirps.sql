@@ -1,27 +1,176 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, original_chirp_id, reply_to_id, visibility)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
 ORDER BY created_at;
 
+-- name: GetFeedChirps :many
+SELECT * FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND visibility = 'public'
+AND kind <> 'rechirp'
+AND (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
 -- name: GetChirpsByUserID :many
 SELECT * FROM chirps
-WHERE user_id = $1
+WHERE user_id = sqlc.arg(user_id)
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
 ORDER BY created_at;
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetChirpForViewer :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg(id)
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+);
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+);
 
--- name: DeleteChirpByID :exec
+-- name: TrashChirp :many
+UPDATE chirps
+SET deleted_at = NOW(), pinned_at = NULL
+WHERE (id = $1 OR (kind = 'rechirp' AND original_chirp_id = $1))
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetTrashedChirp :one
+SELECT chirps.*, (deleted_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::int))::boolean AS restorable
+FROM chirps
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NOT NULL
+FOR UPDATE;
+
+-- name: GetTrash :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND deleted_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::int)
+AND (deleted_at, id) < (sqlc.arg(before_deleted_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: RestoreChirp :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (id = sqlc.arg(id) OR (kind = 'rechirp' AND original_chirp_id = sqlc.arg(id)))
+AND deleted_at = sqlc.arg(deleted_at)::timestamp;
+
+-- name: LockExpiredTrash :many
+SELECT id FROM chirps
+WHERE deleted_at <= NOW() - make_interval(secs => sqlc.arg(window_seconds)::int)
+ORDER BY deleted_at
+LIMIT sqlc.arg(max_chirps)
+FOR UPDATE SKIP LOCKED;
+
+-- name: PurgeChirps :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, visibility, external_id)
+VALUES(
+    gen_random_uuid(),
+    sqlc.arg(created_at),
+    sqlc.arg(created_at),
+    sqlc.arg(body),
+    sqlc.arg(user_id),
+    'original',
+    'public',
+    sqlc.arg(external_id)
+)
+ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING
+RETURNING *;
@@ -0,0 +1,56 @@
+-- name: CreateDraft :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, status, publish_at, visibility)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    'original',
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetDraftsByUser :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND status <> 'published' AND deleted_at IS NULL
+ORDER BY updated_at DESC, id DESC;
+
+-- name: GetDraft :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL;
+
+-- name: GetDraftForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateDraft :one
+UPDATE chirps
+SET body = $1, status = $2, publish_at = $3, visibility = $4, updated_at = NOW()
+WHERE id = $5 AND user_id = $6 AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL;
+
+-- name: LockDueScheduledChirps :many
+SELECT * FROM chirps
+WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: PublishChirp :one
+UPDATE chirps
+SET body = $1, status = 'published', publish_at = NULL, created_at = NOW(), updated_at = NOW()
+WHERE id = $2 AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UnscheduleChirp :exec
+UPDATE chirps
+SET status = 'draft', publish_at = NULL, updated_at = NOW()
+WHERE id = $1 AND status = 'scheduled';
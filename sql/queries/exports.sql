@@ -0,0 +1,87 @@
+-- name: CreateExport :one
+INSERT INTO data_exports (id, user_id, status, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    'pending',
+    NOW()
+)
+RETURNING *;
+
+-- name: GetExport :one
+SELECT * FROM data_exports
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetExportByID :one
+SELECT * FROM data_exports
+WHERE id = $1;
+
+-- name: ClaimExport :one
+UPDATE data_exports
+SET status = 'running', started_at = NOW()
+WHERE id = (
+    SELECT queued.id FROM data_exports AS queued
+    WHERE queued.status = 'pending'
+    OR (queued.status = 'running' AND queued.started_at < NOW() - make_interval(secs => sqlc.arg(stale_seconds)::int))
+    ORDER BY queued.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteExport :exec
+UPDATE data_exports
+SET
+    status = 'ready',
+    storage_key = sqlc.arg(storage_key),
+    size_bytes = sqlc.arg(size_bytes),
+    completed_at = NOW(),
+    expires_at = NOW() + make_interval(secs => sqlc.arg(retention_seconds)::int)
+WHERE id = sqlc.arg(id);
+
+-- name: FailExport :exec
+UPDATE data_exports
+SET status = 'failed', completed_at = NOW()
+WHERE id = $1;
+
+-- name: LockExpiredExports :many
+SELECT id, storage_key FROM data_exports
+WHERE status = 'ready' AND expires_at <= NOW()
+ORDER BY expires_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ExpireExports :exec
+UPDATE data_exports
+SET status = 'expired', storage_key = NULL
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetExportKeysByUser :many
+SELECT storage_key FROM data_exports
+WHERE user_id = $1 AND storage_key IS NOT NULL;
+
+-- name: GetChirpsForExport :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: GetRevisionsForExport :many
+SELECT chirp_revisions.* FROM chirp_revisions
+JOIN chirps ON chirps.id = chirp_revisions.chirp_id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.chirp_id, chirp_revisions.created_at;
+
+-- name: GetLikesForExport :many
+SELECT chirp_id, created_at FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetBookmarksForExport :many
+SELECT chirp_id, created_at FROM bookmarks
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetMediaForExport :many
+SELECT * FROM media
+WHERE user_id = $1
+ORDER BY created_at;
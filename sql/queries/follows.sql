@@ -0,0 +1,29 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT sqlc.arg(follower_id)::uuid, sqlc.arg(followee_id)::uuid, NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(follower_id) AND blocked_id = sqlc.arg(followee_id))
+    OR (blocker_id = sqlc.arg(followee_id) AND blocked_id = sqlc.arg(follower_id))
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id, created_at FROM follows
+WHERE followee_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetFollowing :many
+SELECT followee_id, created_at FROM follows
+WHERE follower_id = $1
+ORDER BY created_at DESC;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1 AND followee_id = $2
+);
@@ -0,0 +1,28 @@
+-- name: ClaimIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+VALUES (sqlc.narg(user_id), sqlc.arg(key), sqlc.arg(request_hash), NOW())
+ON CONFLICT (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'), key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    content_type = NULL,
+    body = NULL,
+    created_at = EXCLUDED.created_at
+WHERE idempotency_keys.created_at < NOW() - make_interval(secs => sqlc.arg(ttl_seconds)::int)
+OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < NOW() - make_interval(secs => sqlc.arg(lock_seconds)::int));
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid AND key = sqlc.arg(key);
+
+-- name: SaveIdempotentResponse :exec
+UPDATE idempotency_keys
+SET status_code = sqlc.arg(status_code), content_type = sqlc.arg(content_type), body = sqlc.arg(body)
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid AND key = sqlc.arg(key);
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid AND key = sqlc.arg(key);
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE created_at < NOW() - make_interval(secs => sqlc.arg(ttl_seconds)::int);
@@ -0,0 +1,37 @@
+-- name: LikeChirp :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetChirpsLikedByUser :many
+SELECT chirps.* FROM chirps
+JOIN chirp_likes ON chirp_likes.chirp_id = chirps.id
+WHERE chirp_likes.user_id = sqlc.arg(user_id)
+AND chirps.status = 'published'
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
+ORDER BY chirp_likes.created_at DESC;
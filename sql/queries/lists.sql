@@ -0,0 +1,74 @@
+-- name: CreateList :one
+INSERT INTO lists (id, user_id, name, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: GetListsByUser :many
+SELECT lists.*, (SELECT COUNT(*) FROM list_members WHERE list_members.list_id = lists.id) AS member_count
+FROM lists
+WHERE lists.user_id = $1
+ORDER BY lists.created_at, lists.id;
+
+-- name: GetList :one
+SELECT lists.*, (SELECT COUNT(*) FROM list_members WHERE list_members.list_id = lists.id) AS member_count
+FROM lists
+WHERE lists.id = $1 AND lists.user_id = $2;
+
+-- name: RenameList :one
+UPDATE lists
+SET name = $1, updated_at = NOW()
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: DeleteList :execrows
+DELETE FROM lists
+WHERE id = $1 AND user_id = $2;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: GetListMembers :many
+SELECT user_id, created_at FROM list_members
+WHERE list_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetListTimeline :many
+SELECT * FROM chirps
+WHERE user_id IN (
+    SELECT list_members.user_id FROM list_members
+    WHERE list_members.list_id = sqlc.arg(list_id)
+)
+AND status = 'published'
+AND deleted_at IS NULL
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
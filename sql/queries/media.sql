@@ -0,0 +1,53 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetAttachableMedia :many
+SELECT media.* FROM media
+WHERE media.user_id = sqlc.arg(user_id)
+AND media.id = ANY(sqlc.arg(ids)::uuid[])
+AND NOT EXISTS (
+    SELECT 1 FROM chirp_media WHERE chirp_media.media_id = media.id
+);
+
+-- name: AttachChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+SELECT sqlc.arg(chirp_id)::uuid, attached.media_id, attached.position::integer - 1
+FROM unnest(sqlc.arg(media_ids)::uuid[]) WITH ORDINALITY AS attached(media_id, position);
+
+-- name: GetMediaForChirps :many
+SELECT chirp_media.chirp_id, media.id, media.content_type, media.width, media.height, media.storage_key, media.thumbnail_key
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: GetMediaForPurgedChirps :many
+SELECT media.id, media.storage_key, media.thumbnail_key
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: DeleteMediaByIDs :exec
+DELETE FROM media
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetMediaOwnerByKey :one
+SELECT media.user_id, chirp_media.chirp_id FROM media
+LEFT JOIN chirp_media ON chirp_media.media_id = media.id
+WHERE media.storage_key = $1 OR media.thumbnail_key = $1;
+
+-- name: GetMediaKeysByUser :many
+SELECT storage_key, thumbnail_key FROM media
+WHERE user_id = $1;
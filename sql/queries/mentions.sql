@@ -0,0 +1,55 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg(handles)::text[]);
+
+-- name: GetMentionedUserIDs :many
+SELECT DISTINCT user_id FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: InsertChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+SELECT
+    sqlc.arg(chirp_id)::uuid,
+    unnest(sqlc.arg(user_ids)::uuid[]),
+    unnest(sqlc.arg(start_offsets)::int[]),
+    unnest(sqlc.arg(end_offsets)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetMentionsForChirps :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, users.handle, chirp_mentions.start_offset, chirp_mentions.end_offset
+FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset;
+
+-- name: GetMentionsOfUser :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_mentions.chirp_id FROM chirp_mentions
+    WHERE chirp_mentions.user_id = sqlc.arg(user_id)
+)
+AND deleted_at IS NULL
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(user_id))
+    OR (user_blocks.blocker_id = sqlc.arg(user_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(user_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(user_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(user_id) AND follows.followee_id = chirps.user_id
+    ))
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,81 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, direct_key, created_at, updated_at)
+VALUES (gen_random_uuid(), $1, NOW(), NOW())
+ON CONFLICT (direct_key) DO NOTHING
+RETURNING *;
+
+-- name: GetConversationByDirectKey :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: AddConversationMembers :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+SELECT sqlc.arg(conversation_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationsForUser :many
+SELECT
+    conversations.*,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id IS DISTINCT FROM conversation_members.user_id
+        AND (conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at)
+    ) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg(user_id)
+ORDER BY conversations.updated_at DESC, conversations.id DESC;
+
+-- name: GetConversationForMember :one
+SELECT
+    conversations.*,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id IS DISTINCT FROM conversation_members.user_id
+        AND (conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at)
+    ) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id) AND conversation_members.user_id = sqlc.arg(user_id);
+
+-- name: GetConversationMembers :many
+SELECT conversation_id, user_id, last_read_at FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM messages
+JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+WHERE conversation_members.user_id = sqlc.arg(user_id)
+AND messages.sender_id IS DISTINCT FROM conversation_members.user_id
+AND (conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at);
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, NOW())
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = $1
+WHERE id = $2;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetMessageForMember :one
+SELECT messages.* FROM messages
+JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+WHERE messages.id = sqlc.arg(id) AND conversation_members.user_id = sqlc.arg(user_id);
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = sqlc.arg(read_at)::timestamp
+WHERE conversation_id = sqlc.arg(conversation_id) AND user_id = sqlc.arg(user_id)
+AND (last_read_at IS NULL OR last_read_at < sqlc.arg(read_at)::timestamp);
@@ -0,0 +1,74 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, created_at, user_id, type, actor_id, chirp_id)
+SELECT
+    gen_random_uuid(),
+    NOW(),
+    sqlc.arg(user_id)::uuid,
+    sqlc.arg(type)::text,
+    sqlc.narg(actor_id)::uuid,
+    sqlc.narg(chirp_id)::uuid
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = sqlc.arg(user_id)::uuid
+    AND notification_preferences.type = sqlc.arg(type)::text
+    AND NOT notification_preferences.enabled
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(user_id)::uuid
+    AND user_mutes.muted_id = sqlc.narg(actor_id)::uuid
+)
+RETURNING id;
+
+-- name: GetNotifications :many
+SELECT
+    notifications.id,
+    notifications.created_at,
+    notifications.type,
+    notifications.actor_id,
+    users.handle AS actor_handle,
+    notifications.chirp_id,
+    notifications.read_at
+FROM notifications
+LEFT JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND (notifications.created_at, notifications.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetNotificationForUser :one
+SELECT
+    notifications.id,
+    notifications.created_at,
+    notifications.type,
+    notifications.actor_id,
+    users.handle AS actor_handle,
+    notifications.chirp_id,
+    notifications.read_at
+FROM notifications
+LEFT JOIN users ON users.id = notifications.actor_id
+WHERE notifications.id = sqlc.arg(id) AND notifications.user_id = sqlc.arg(user_id);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND notifications.read_at IS NULL
+AND (notifications.created_at, notifications.id) <= (
+    SELECT upto.created_at, upto.id FROM notifications AS upto
+    WHERE upto.id = sqlc.arg(up_to_id) AND upto.user_id = sqlc.arg(user_id)
+);
+
+-- name: GetNotificationPreferences :many
+SELECT type, enabled FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = NOW();
@@ -0,0 +1,34 @@
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND pinned_at IS NOT NULL;
+
+-- name: PinChirp :exec
+UPDATE chirps
+SET pinned_at = NOW()
+WHERE id = $1 AND pinned_at IS NULL;
+
+-- name: UnpinChirp :exec
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND pinned_at IS NOT NULL
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
+ORDER BY pinned_at DESC;
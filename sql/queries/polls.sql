@@ -0,0 +1,47 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, multiple, closes_at, created_at)
+VALUES ($1, $2, $3, NOW());
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (chirp_id, position, text)
+SELECT sqlc.arg(chirp_id)::uuid, options.position::integer - 1, options.text
+FROM unnest(sqlc.arg(texts)::text[]) WITH ORDINALITY AS options(text, position);
+
+-- name: GetPoll :one
+SELECT polls.*, (SELECT COUNT(*) FROM poll_options WHERE poll_options.chirp_id = polls.chirp_id) AS option_count
+FROM polls
+WHERE polls.chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT
+    polls.chirp_id,
+    polls.multiple,
+    polls.closes_at,
+    (polls.closes_at <= NOW())::boolean AS closed,
+    (SELECT COUNT(*) FROM poll_voters WHERE poll_voters.chirp_id = polls.chirp_id) AS voter_count,
+    poll_options.position,
+    poll_options.text,
+    (
+        SELECT COUNT(*) FROM poll_votes
+        WHERE poll_votes.chirp_id = poll_options.chirp_id AND poll_votes.position = poll_options.position
+    ) AS vote_count
+FROM polls
+JOIN poll_options ON poll_options.chirp_id = polls.chirp_id
+WHERE polls.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY polls.chirp_id, poll_options.position;
+
+-- name: GetPollVotesByUser :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = $1 AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: AddPollVoter :execrows
+INSERT INTO poll_voters (chirp_id, user_id, created_at)
+SELECT polls.chirp_id, sqlc.arg(user_id)::uuid, NOW()
+FROM polls
+WHERE polls.chirp_id = sqlc.arg(chirp_id) AND polls.closes_at > NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: InsertPollVotes :exec
+INSERT INTO poll_votes (chirp_id, user_id, position)
+SELECT sqlc.arg(chirp_id)::uuid, sqlc.arg(user_id)::uuid, unnest(sqlc.arg(positions)::int[]);
@@ -0,0 +1,35 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, original_chirp_id)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    'rechirp',
+    $2
+)
+ON CONFLICT (user_id, original_chirp_id) WHERE kind = 'rechirp' AND deleted_at IS NULL DO NOTHING
+RETURNING *;
+
+-- name: GetRechirp :one
+SELECT * FROM chirps
+WHERE user_id = $1 AND original_chirp_id = $2 AND kind = 'rechirp' AND deleted_at IS NULL;
+
+-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1 AND original_chirp_id = $2 AND kind = 'rechirp' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetRechirpsOfChirp :many
+SELECT user_id, created_at FROM chirps
+WHERE original_chirp_id = $1 AND kind = 'rechirp' AND deleted_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: DeleteRechirpsOfUser :exec
+DELETE FROM chirps
+WHERE kind = 'rechirp'
+AND original_chirp_id IN (
+    SELECT id FROM chirps AS originals
+    WHERE originals.user_id = $1
+);
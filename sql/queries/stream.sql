@@ -0,0 +1,8 @@
+-- name: LockStreamChannel :exec
+SELECT pg_advisory_xact_lock(hashtext(sqlc.arg(channel)::text));
+
+-- name: NextStreamEventID :one
+SELECT nextval('stream_event_id_seq')::bigint AS id;
+
+-- name: NotifyStreamEvent :exec
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
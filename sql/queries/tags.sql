@@ -0,0 +1,55 @@
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: InsertChirpTags :exec
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT sqlc.arg(chirp_id)::uuid, unnest(sqlc.arg(tags)::text[]), sqlc.arg(created_at)::timestamp
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByTag :many
+SELECT chirps.* FROM chirp_tags
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.tag = sqlc.arg(tag)
+AND chirps.deleted_at IS NULL
+AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(viewer_id))
+    OR (user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(viewer_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = sqlc.arg(viewer_id)
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(viewer_id) AND follows.followee_id = chirps.user_id
+    ))
+)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteTrendingTags :exec
+DELETE FROM trending_tags
+WHERE time_window = $1;
+
+-- name: InsertTrendingTags :exec
+INSERT INTO trending_tags (time_window, tag, chirp_count, rank, computed_at)
+SELECT sqlc.arg(time_window)::text, tag, COUNT(*), RANK() OVER (ORDER BY COUNT(*) DESC), NOW()
+FROM chirp_tags
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::int)
+AND chirps.visibility = 'public'
+AND chirps.deleted_at IS NULL
+GROUP BY tag
+ORDER BY COUNT(*) DESC, tag
+LIMIT sqlc.arg(max_tags);
+
+-- name: GetTrendingTags :many
+SELECT * FROM trending_tags
+WHERE time_window = $1
+ORDER BY rank, tag;
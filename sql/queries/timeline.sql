@@ -0,0 +1,46 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = $1
+AND chirps.visibility <> 'private'
+UNION ALL
+SELECT chirps.user_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.id = $1
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(follower_id)::uuid, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg(followee_id)
+AND chirps.status = 'published'
+AND chirps.deleted_at IS NULL
+AND chirps.visibility <> 'private'
+ORDER BY chirps.created_at DESC
+LIMIT 200
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveAuthorFromTimeline :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1 AND author_id = $2;
+
+-- name: GetTimeline :many
+SELECT chirps.* FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.arg(user_id) AND user_mutes.muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = sqlc.arg(user_id) AND user_blocks.blocked_id = chirps.user_id)
+    OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.arg(user_id))
+)
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT sqlc.arg(page_size);
@@ -1,13 +1,14 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, chirpy_red;
+RETURNING *;
 
 -- name: ResetUser :exec
 DELETE FROM users;
@@ -16,13 +17,83 @@ DELETE FROM users;
 SELECT * FROM users
 WHERE email = $1;
 
--- name: UpdatePasswordByID :one
+-- name: UpdateUserPassword :exec
 UPDATE users
-SET hashed_password = $1, updated_at = NOW(), email = $2
-WHERE id = $3
-RETURNING id, created_at, updated_at, email, chirpy_red;
+SET hashed_password = $1, updated_at = NOW()
+WHERE id = $2;
 
--- name: UpgradeUserByID :exec
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    email = COALESCE(sqlc.narg(email)::text, email),
+    handle = COALESCE(sqlc.narg(handle)::text, handle),
+    display_name = COALESCE(sqlc.narg(display_name)::text, display_name),
+    bio = COALESCE(sqlc.narg(bio)::text, bio),
+    avatar_url = COALESCE(sqlc.narg(avatar_url)::text, avatar_url),
+    location = COALESCE(sqlc.narg(location)::text, location),
+    dms_from_followers_only = COALESCE(sqlc.narg(dms_from_followers_only)::boolean, dms_from_followers_only),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpgradeUserByID :execrows
 UPDATE users
 SET chirpy_red = TRUE, updated_at = NOW()
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND chirpy_red = FALSE;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetPublicProfileByHandle :one
+SELECT
+    users.id,
+    users.created_at,
+    users.handle,
+    users.display_name,
+    users.bio,
+    users.avatar_url,
+    users.location,
+    users.chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND chirps.deleted_at IS NULL AND chirps.visibility IN ('public', 'unlisted')) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE LOWER(users.handle) = LOWER(sqlc.arg(handle)::text);
+
+-- name: IsActiveUser :one
+SELECT EXISTS (
+    SELECT 1 FROM users
+    WHERE id = $1 AND delete_after IS NULL
+);
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET delete_after = NOW() + make_interval(secs => sqlc.arg(grace_seconds)::int), updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING delete_after;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users
+SET delete_after = NULL, updated_at = NOW()
+WHERE id = $1 AND delete_after > NOW();
+
+-- name: LockDueUserDeletions :many
+SELECT id FROM users
+WHERE delete_after <= NOW()
+ORDER BY delete_after
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: GetHandlesByIDs :many
+SELECT id, handle FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
@@ -0,0 +1,103 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, user_id, url, secret, events, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW())
+RETURNING *;
+
+-- name: GetWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid
+ORDER BY created_at;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = sqlc.arg(id) AND user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = sqlc.arg(id) AND user_id IS NOT DISTINCT FROM sqlc.narg(user_id)::uuid;
+
+-- name: CreateWebhookEvent :exec
+INSERT INTO webhook_events (id, user_id, type, payload, dispatched_at, created_at)
+VALUES ($1, $2, $3, $4, $5, NOW());
+
+-- name: LockUndispatchedWebhookEvents :many
+SELECT * FROM webhook_events
+WHERE dispatched_at IS NULL
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateWebhookDeliveriesForEvent :exec
+INSERT INTO webhook_deliveries (id, endpoint_id, event_id, next_attempt_at, created_at)
+SELECT gen_random_uuid(), webhook_endpoints.id, sqlc.arg(event_id)::uuid, NOW(), NOW()
+FROM webhook_endpoints
+WHERE (webhook_endpoints.user_id IS NULL OR webhook_endpoints.user_id = sqlc.narg(user_id)::uuid)
+AND sqlc.arg(type)::text = ANY(webhook_endpoints.events);
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, endpoint_id, event_id, next_attempt_at, created_at)
+VALUES (gen_random_uuid(), $1, $2, NOW(), NOW());
+
+-- name: MarkWebhookEventDispatched :exec
+UPDATE webhook_events
+SET dispatched_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET attempts = webhook_deliveries.attempts + 1, next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+FROM webhook_endpoints CROSS JOIN webhook_events
+WHERE webhook_deliveries.id IN (
+    SELECT due.id FROM webhook_deliveries AS due
+    WHERE due.status = 'pending' AND due.next_attempt_at <= NOW()
+    ORDER BY due.next_attempt_at
+    LIMIT sqlc.arg(max_deliveries)
+    FOR UPDATE SKIP LOCKED
+)
+AND webhook_endpoints.id = webhook_deliveries.endpoint_id
+AND webhook_events.id = webhook_deliveries.event_id
+RETURNING webhook_deliveries.id, webhook_deliveries.attempts, webhook_endpoints.url, webhook_endpoints.secret, webhook_events.type, webhook_events.payload;
+
+-- name: CompleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: RetryWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg(delay_seconds)::int),
+    last_error = sqlc.arg(last_error),
+    last_status_code = sqlc.narg(last_status_code)
+WHERE id = sqlc.arg(id);
+
+-- name: KillWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'dead', last_error = sqlc.arg(last_error), last_status_code = sqlc.narg(last_status_code)
+WHERE id = sqlc.arg(id);
+
+-- name: GetDeadWebhookDeliveries :many
+SELECT
+    webhook_deliveries.id,
+    webhook_deliveries.event_id,
+    webhook_events.type,
+    webhook_events.payload,
+    webhook_deliveries.attempts,
+    webhook_deliveries.last_error,
+    webhook_deliveries.last_status_code,
+    webhook_deliveries.created_at
+FROM webhook_deliveries
+JOIN webhook_events ON webhook_events.id = webhook_deliveries.event_id
+WHERE webhook_deliveries.endpoint_id = $1 AND webhook_deliveries.status = 'dead'
+ORDER BY webhook_deliveries.created_at DESC;
+
+-- name: RequeueDeadWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+WHERE id = $1 AND endpoint_id = $2 AND status = 'dead';
+
+-- name: DeleteFinishedWebhookEvents :exec
+DELETE FROM webhook_events
+WHERE dispatched_at < NOW() - make_interval(secs => sqlc.arg(window_seconds)::int)
+AND NOT EXISTS (
+    SELECT 1 FROM webhook_deliveries
+    WHERE webhook_deliveries.event_id = webhook_events.id
+);
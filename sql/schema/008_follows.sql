@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
+
+-- Home timelines are materialized at write time so reading one never has to
+-- join across every chirp from every followed account.
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_idx
+ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+
+CREATE INDEX timeline_entries_user_id_author_id_idx
+ON timeline_entries (user_id, author_id);
+
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT user_id, id, user_id, created_at FROM chirps;
+
+-- +goose Down
+DROP TABLE timeline_entries;
+DROP TABLE follows;
@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE chirp_tags (
+    chirp_id UUID NOT NULL
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+CREATE INDEX chirp_tags_tag_created_at_idx ON chirp_tags (tag, created_at DESC);
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT chirps.id, LOWER(m[1]), chirps.created_at
+FROM chirps, regexp_matches(chirps.body, '(?:^|[^[:alnum:]_&])#([[:alnum:]_]+)', 'g') AS m
+WHERE m[1] ~ '[[:alpha:]]'
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE chirp_revisions (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, created_at);
+
+-- Written only by the background aggregator; one ranked list per window.
+CREATE TABLE trending_tags (
+    time_window TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    chirp_count BIGINT NOT NULL,
+    rank INTEGER NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (time_window, tag)
+);
+
+-- +goose Down
+DROP TABLE trending_tags;
+DROP TABLE chirp_revisions;
+DROP TABLE chirp_tags;
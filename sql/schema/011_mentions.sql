@@ -0,0 +1,41 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT NULL;
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (LOWER(handle));
+
+-- Offsets are character (not byte) positions of the "@handle" token in the
+-- chirp body, end exclusive.
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    actor_id UUID NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NULL
+    REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE chirp_mentions;
+DROP INDEX users_handle_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN handle;
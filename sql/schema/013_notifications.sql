@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN reply_to_id UUID NULL
+REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_reply_to_id_idx ON chirps (reply_to_id);
+
+-- Notifications created before read tracking existed are treated as read.
+ALTER TABLE notifications
+ADD COLUMN read_at TIMESTAMP NULL;
+
+UPDATE notifications SET read_at = created_at;
+
+ALTER TABLE notifications
+ADD CONSTRAINT notifications_type_check
+CHECK (type IN ('like', 'reply', 'mention', 'follow', 'moderation', 'chirpy_red'));
+
+CREATE INDEX notifications_user_id_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+-- A missing row means the notification type is enabled.
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP INDEX notifications_user_id_unread_idx;
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_type_check;
+
+ALTER TABLE notifications
+DROP COLUMN read_at;
+
+DROP INDEX chirps_reply_to_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN reply_to_id;
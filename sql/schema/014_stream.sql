@@ -0,0 +1,7 @@
+-- +goose Up
+-- Stream event IDs come from a sequence so that every instance sharing the
+-- database hands out the same, increasing IDs for Last-Event-ID resume.
+CREATE SEQUENCE stream_event_id_seq;
+
+-- +goose Down
+DROP SEQUENCE stream_event_id_seq;
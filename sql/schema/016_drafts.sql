@@ -0,0 +1,23 @@
+-- +goose Up
+-- Drafts and scheduled chirps live in the chirps table but stay out of every
+-- feed, and out of tags, mentions and timelines, until they are published.
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+CHECK (status IN ('draft', 'scheduled', 'published')),
+ADD COLUMN publish_at TIMESTAMP NULL;
+
+ALTER TABLE chirps
+ADD CONSTRAINT chirps_scheduled_publish_at_check
+CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX chirps_scheduled_publish_at_idx ON chirps (publish_at) WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX chirps_scheduled_publish_at_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_scheduled_publish_at_check;
+
+ALTER TABLE chirps
+DROP COLUMN publish_at,
+DROP COLUMN status;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- public chirps appear everywhere. unlisted chirps can be read by anyone who
+-- has the link and appear on the author's profile and in timelines, but not
+-- in the global or hashtag feeds. followers chirps are visible to the
+-- author's followers and private chirps to the author alone.
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'));
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN visibility;
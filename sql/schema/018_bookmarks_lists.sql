@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+-- Lists are private to their owner. A list timeline is read from chirps
+-- directly rather than materialized like the home timeline.
+CREATE TABLE lists (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX lists_user_id_idx ON lists (user_id);
+
+CREATE TABLE list_members (
+    list_id UUID NOT NULL
+    REFERENCES lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX chirps_user_id_created_at_idx;
+DROP TABLE list_members;
+DROP TABLE lists;
+DROP TABLE bookmarks;
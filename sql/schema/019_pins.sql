@@ -0,0 +1,12 @@
+-- +goose Up
+-- A pin lives on the chirp itself, so deleting the chirp also unpins it.
+ALTER TABLE chirps
+ADD COLUMN pinned_at TIMESTAMP NULL;
+
+CREATE INDEX chirps_pinned_idx ON chirps (user_id, pinned_at DESC) WHERE pinned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_pinned_idx;
+
+ALTER TABLE chirps
+DROP COLUMN pinned_at;
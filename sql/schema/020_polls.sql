@@ -0,0 +1,46 @@
+-- +goose Up
+-- A poll closes on its own once closes_at passes: votes are only accepted,
+-- and tallies only revealed to non-voters, by comparing it with NOW().
+CREATE TABLE polls (
+    chirp_id UUID PRIMARY KEY
+    REFERENCES chirps(id) ON DELETE CASCADE,
+    multiple BOOLEAN NOT NULL DEFAULT FALSE,
+    closes_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options (
+    chirp_id UUID NOT NULL
+    REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, position)
+);
+
+-- One row per user who voted. Inserting it first is what limits a user to a
+-- single vote set, even when requests race.
+CREATE TABLE poll_voters (
+    chirp_id UUID NOT NULL
+    REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE TABLE poll_votes (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, user_id, position),
+    FOREIGN KEY (chirp_id, user_id) REFERENCES poll_voters(chirp_id, user_id) ON DELETE CASCADE,
+    FOREIGN KEY (chirp_id, position) REFERENCES poll_options(chirp_id, position) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_chirp_id_position_idx ON poll_votes (chirp_id, position);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_voters;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,46 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN dms_from_followers_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- direct_key is set on one-to-one conversations to the two member IDs in
+-- sorted order, so that a pair of users always shares a single conversation.
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    direct_key TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- last_read_at is the read receipt: messages up to it have been read.
+CREATE TABLE conversation_members (
+    conversation_id UUID NOT NULL
+    REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP NULL,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_members_user_id_idx ON conversation_members (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL
+    REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NULL
+    REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx
+ON messages (conversation_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
+
+ALTER TABLE users
+DROP COLUMN dms_from_followers_only;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Deleted chirps stay in the trash until the purge job removes them, so
+-- every read path filters on deleted_at IS NULL.
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A rechirp in the trash must not stop the user from rechirping again.
+DROP INDEX chirps_one_rechirp_per_user_idx;
+
+CREATE UNIQUE INDEX chirps_one_rechirp_per_user_idx ON chirps (user_id, original_chirp_id)
+WHERE kind = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DELETE FROM chirps WHERE deleted_at IS NOT NULL;
+
+DROP INDEX chirps_one_rechirp_per_user_idx;
+
+CREATE UNIQUE INDEX chirps_one_rechirp_per_user_idx ON chirps (user_id, original_chirp_id)
+WHERE kind = 'rechirp';
+
+DROP INDEX chirps_deleted_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
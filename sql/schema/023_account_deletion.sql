@@ -0,0 +1,14 @@
+-- +goose Up
+-- An account with delete_after set is pending deletion: its tokens are
+-- rejected, and once delete_after passes the account purger removes the row
+-- and everything that cascades from it.
+ALTER TABLE users
+ADD COLUMN delete_after TIMESTAMP NULL;
+
+CREATE INDEX users_delete_after_idx ON users (delete_after) WHERE delete_after IS NOT NULL;
+
+-- +goose Down
+DROP INDEX users_delete_after_idx;
+
+ALTER TABLE users
+DROP COLUMN delete_after;
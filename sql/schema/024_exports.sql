@@ -0,0 +1,27 @@
+-- +goose Up
+-- Export archives are built by a background worker and kept in a blob store
+-- of their own, apart from the media store that /media/{key} serves.
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'running', 'ready', 'failed', 'expired')),
+    created_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    storage_key TEXT NULL,
+    size_bytes BIGINT NULL
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id, created_at DESC);
+
+CREATE INDEX data_exports_queue_idx ON data_exports (created_at) WHERE status IN ('pending', 'running');
+
+-- Only one export per user can be waiting or in progress.
+CREATE UNIQUE INDEX data_exports_one_active_per_user_idx ON data_exports (user_id)
+WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP TABLE data_exports;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Imported chirps keep the ID they had on their original platform, so
+-- importing the same archive twice does not duplicate them.
+ALTER TABLE chirps
+ADD COLUMN external_id TEXT NULL;
+
+CREATE UNIQUE INDEX chirps_user_id_external_id_idx ON chirps (user_id, external_id)
+WHERE external_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_external_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN external_id;
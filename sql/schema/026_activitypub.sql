@@ -0,0 +1,54 @@
+-- +goose Up
+-- Each local user signs outgoing activities with their own RSA key pair,
+-- generated the first time it is needed.
+ALTER TABLE users
+ADD COLUMN ap_private_key TEXT NULL,
+ADD COLUMN ap_public_key TEXT NULL;
+
+-- Remote actors are cached so inbox signatures can be checked without
+-- fetching the sender's key on every request.
+CREATE TABLE remote_actors (
+    actor_url TEXT PRIMARY KEY,
+    inbox_url TEXT NOT NULL,
+    shared_inbox_url TEXT NULL,
+    key_id TEXT NOT NULL UNIQUE,
+    public_key_pem TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE remote_followers (
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    actor_url TEXT NOT NULL
+    REFERENCES remote_actors(actor_url) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, actor_url)
+);
+
+-- Outgoing activities wait here until their inbox accepts them. A worker
+-- claims due rows by pushing next_attempt_at forward, so a crashed worker's
+-- deliveries are picked up again once that lease runs out.
+CREATE TABLE ap_deliveries (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    inbox_url TEXT NOT NULL,
+    activity TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ap_deliveries_due_idx ON ap_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE ap_deliveries;
+DROP TABLE remote_followers;
+DROP TABLE remote_actors;
+
+ALTER TABLE users
+DROP COLUMN ap_public_key,
+DROP COLUMN ap_private_key;
@@ -0,0 +1,55 @@
+-- +goose Up
+-- Endpoints without an owner are registered by an admin and receive events
+-- about every user. The others only receive events about their owner.
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY,
+    user_id UUID NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_endpoints_user_id_idx ON webhook_endpoints (user_id);
+
+-- The outbox. Events are written in the same transaction as the change they
+-- describe, so an event is recorded if and only if the change commits. A
+-- worker later fans each event out to the endpoints subscribed to it.
+CREATE TABLE webhook_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    dispatched_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_events_undispatched_idx ON webhook_events (created_at) WHERE dispatched_at IS NULL;
+
+-- Delivered rows are deleted. Deliveries that run out of attempts are kept
+-- as 'dead' until they are retried or their endpoint is removed.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    endpoint_id UUID NOT NULL
+    REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL
+    REFERENCES webhook_events(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NULL,
+    last_status_code INTEGER NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_endpoint_id_idx ON webhook_deliveries (endpoint_id, created_at);
+CREATE INDEX webhook_deliveries_event_id_idx ON webhook_deliveries (event_id);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_events;
+DROP TABLE webhook_endpoints;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Responses to POST requests sent with an Idempotency-Key header. A row with
+-- a NULL status_code is a request still being handled. Anonymous requests
+-- have a NULL user_id and share one key space.
+CREATE TABLE idempotency_keys (
+    user_id UUID NULL
+    REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NULL,
+    content_type TEXT NULL,
+    body BYTEA NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idempotency_keys_user_key_idx
+ON idempotency_keys (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'), key);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
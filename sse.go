@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/chirptext"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	streamHistorySize       = 1000
+	streamBufferSize        = 64
+	streamHeartbeatInterval = 15 * time.Second
+	streamWriteTimeout      = 10 * time.Second
+)
+
+// publishStreamEvent announces a change to dbChirp to live subscribers.
+// Failures are logged; streaming is best effort.
+func (cfg *apiConfig) publishStreamEvent(ctx context.Context, kind string, dbChirp database.Chirp) {
+	e := stream.Event{
+		Type:       kind,
+		ChirpID:    dbChirp.ID,
+		AuthorID:   dbChirp.UserID,
+		ReplyToID:  dbChirp.ReplyToID,
+		Tags:       chirptext.ExtractHashtags(dbChirp.Body),
+		Visibility: dbChirp.Visibility,
+	}
+	if err := cfg.broker.Publish(ctx, e); err != nil {
+		log.Printf("Failed to publish %s event for chirp %s: %v", kind, dbChirp.ID, err)
+	}
+}
+
+// listed reports whether e belongs in the global and hashtag feeds, which
+// leave out other users' unlisted chirps.
+func listed(viewerID uuid.UUID, e stream.Event) bool {
+	return e.Visibility != visibilityUnlisted || e.AuthorID == viewerID
+}
+
+// streamFilter returns the predicate selecting the events of the requested
+// feed, or writes an error response and returns false. The timeline feed uses
+// the caller's follows at the time of connecting.
+func (cfg *apiConfig) streamFilter(w http.ResponseWriter, r *http.Request) (func(stream.Event) bool, uuid.UUID, bool) {
+	query := r.URL.Query()
+	viewerID := cfg.viewerID(r)
+
+	switch query.Get("feed") {
+	case "", "global":
+		return func(e stream.Event) bool { return listed(viewerID, e) }, viewerID, true
+
+	case "author":
+		authorID, err := uuid.Parse(query.Get("author_id"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID format")
+			return nil, uuid.Nil, false
+		}
+		return func(e stream.Event) bool { return e.AuthorID == authorID }, viewerID, true
+
+	case "tag":
+		tag := chirptext.NormalizeTag(query.Get("tag"))
+		if tag == "" {
+			respondWithError(w, http.StatusBadRequest, "Invalid tag")
+			return nil, uuid.Nil, false
+		}
+		return func(e stream.Event) bool {
+			if !listed(viewerID, e) {
+				return false
+			}
+			for _, t := range e.Tags {
+				if t == tag {
+					return true
+				}
+			}
+			return false
+		}, viewerID, true
+
+	case "timeline":
+		userID, ok := cfg.authenticatedUserID(w, r)
+		if !ok {
+			return nil, uuid.Nil, false
+		}
+
+		following, err := cfg.db.GetFollowing(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve follows")
+			return nil, uuid.Nil, false
+		}
+		authors := map[uuid.UUID]bool{userID: true}
+		for _, f := range following {
+			authors[f.FolloweeID] = true
+		}
+		return func(e stream.Event) bool { return authors[e.AuthorID] }, userID, true
+
+	default:
+		respondWithError(w, http.StatusBadRequest, "feed must be one of global, author, tag or timeline")
+		return nil, uuid.Nil, false
+	}
+}
+
+// streamEventData renders the data line of e for viewerID. It reports false
+// when the viewer may not see the chirp, for example across a block.
+func (cfg *apiConfig) streamEventData(ctx context.Context, viewerID uuid.UUID, e stream.Event) ([]byte, bool, error) {
+	if e.Type == stream.ChirpDeleted {
+		visible, err := cfg.canSeeChirp(ctx, viewerID, e.AuthorID, e.Visibility)
+		if err != nil || !visible {
+			return nil, false, err
+		}
+		if viewerID != uuid.Nil && viewerID != e.AuthorID {
+			blocked, err := cfg.db.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+				UserA: viewerID,
+				UserB: e.AuthorID,
+			})
+			if err != nil || blocked {
+				return nil, false, err
+			}
+		}
+		data, err := json.Marshal(map[string]string{"id": e.ChirpID.String()})
+		return data, true, err
+	}
+
+	dbChirp, err := cfg.db.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+		ID:       e.ChirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	chirp, err := cfg.buildChirp(ctx, viewerID, dbChirp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(chirp)
+	return data, true, err
+}
+
+func (cfg *apiConfig) streamHandler(w http.ResponseWriter, r *http.Request) {
+	match, viewerID, ok := cfg.streamFilter(w, r)
+	if !ok {
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastEventID != "" {
+		var err error
+		after, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || after < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	muted := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		dbMutes, err := cfg.db.GetMutedUsers(r.Context(), viewerID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve mutes")
+			return
+		}
+		for _, m := range dbMutes {
+			muted[m.MutedID] = true
+		}
+	}
+
+	sub := cfg.broker.Subscribe(after)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// A client that cannot keep up is disconnected by the broker rather than
+	// holding back other subscribers; the write deadline does the same for a
+	// stalled connection. Either way the client resumes with Last-Event-ID.
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...any) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && err != http.ErrNotSupported {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err := write("retry: %d\n\n", 2000); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if err := write(": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					log.Printf("Stream subscription ended: %v", err)
+				}
+				return
+			}
+			if !e.IsChirpEvent() || !match(e) || muted[e.AuthorID] {
+				continue
+			}
+
+			data, visible, err := cfg.streamEventData(r.Context(), viewerID, e)
+			if err != nil {
+				log.Printf("Failed to render %s event for chirp %s: %v", e.Type, e.ChirpID, err)
+				continue
+			}
+			if !visible {
+				continue
+			}
+
+			if err := write("id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VMT1312/Chirpy/internal/chirptext"
+	"github.com/VMT1312/Chirpy/internal/database"
+)
+
+// saveChirpTags replaces the stored hashtags of dbChirp with the ones in its
+// current body. q may be bound to a transaction.
+func saveChirpTags(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	if err := q.DeleteChirpTags(ctx, dbChirp.ID); err != nil {
+		return err
+	}
+
+	tags := chirptext.ExtractHashtags(dbChirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return q.InsertChirpTags(ctx, database.InsertChirpTagsParams{
+		ChirpID:   dbChirp.ID,
+		Tags:      tags,
+		CreatedAt: dbChirp.CreatedAt,
+	})
+}
+
+func (cfg *apiConfig) getChirpsByTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag := chirptext.NormalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag")
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.db.GetChirpsByTag(r.Context(), database.GetChirpsByTagParams{
+		Tag:             tag,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		ViewerID:        viewerID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, ChirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor(dbChirps, pageSize),
+	})
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/cursor"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	// trashRetention is how long a deleted chirp can be restored before the
+	// purge job removes it for good.
+	trashRetention      = 30 * 24 * time.Hour
+	trashPurgeBatchSize = 100
+)
+
+func (cfg *apiConfig) restoreChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	trashed, err := q.GetTrashedChirp(r.Context(), database.GetTrashedChirpParams{
+		WindowSeconds: int32(trashRetention.Seconds()),
+		ID:            chirpID,
+		UserID:        userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Chirp not found in trash")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp")
+		return
+	}
+	if !trashed.Restorable {
+		respondWithError(w, http.StatusGone, "The restore window for this chirp has passed")
+		return
+	}
+
+	// Rechirps deleted along with the chirp come back with it.
+	err = q.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:        chirpID,
+		DeletedAt: trashed.DeletedAt.Time,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "You have rechirped this chirp again since deleting this rechirp")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp")
+		return
+	}
+
+	dbChirp, err := q.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp")
+		return
+	}
+
+	if dbChirp.Status == "published" {
+		cfg.publishStreamEvent(r.Context(), stream.ChirpCreated, dbChirp)
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, chirp)
+}
+
+// getTrashHandler lists the caller's deleted chirps that can still be
+// restored, most recently deleted first.
+func (cfg *apiConfig) getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	before, pageSize, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbChirps, err := cfg.db.GetTrash(r.Context(), database.GetTrashParams{
+		UserID:          userID,
+		WindowSeconds:   int32(trashRetention.Seconds()),
+		BeforeDeletedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve trash")
+		return
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve trash")
+		return
+	}
+
+	// The trash is ordered by deletion time, so its cursor carries
+	// deleted_at rather than created_at.
+	next := ""
+	if len(dbChirps) == int(pageSize) {
+		last := dbChirps[len(dbChirps)-1]
+		next = cursor.Encode(cursor.Cursor{CreatedAt: last.DeletedAt.Time, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, ChirpPage{
+		Chirps:     chirps,
+		NextCursor: next,
+	})
+}
+
+// runTrashPurger permanently deletes chirps that have been in the trash
+// longer than trashRetention, along with their media.
+func (cfg *apiConfig) runTrashPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.purgeExpiredTrash(context.Background())
+			if err != nil {
+				log.Printf("Failed to purge trash: %v", err)
+				break
+			}
+			if n < trashPurgeBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// purgeExpiredTrash purges one batch of expired chirps and reports how many
+// it removed. Blobs are deleted only after the rows are gone, so a failure
+// leaves an orphaned blob rather than a chirp pointing at missing media.
+func (cfg *apiConfig) purgeExpiredTrash(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	ids, err := q.LockExpiredTrash(ctx, database.LockExpiredTrashParams{
+		WindowSeconds: int32(trashRetention.Seconds()),
+		MaxChirps:     trashPurgeBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	media, err := q.GetMediaForPurgedChirps(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := q.PurgeChirps(ctx, ids); err != nil {
+		return 0, err
+	}
+	mediaIDs := make([]uuid.UUID, len(media))
+	for i, m := range media {
+		mediaIDs[i] = m.ID
+	}
+	if err := q.DeleteMediaByIDs(ctx, mediaIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, m := range media {
+		for _, key := range []string{m.StorageKey, m.ThumbnailKey} {
+			if err := cfg.blobs.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+)
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+const (
+	defaultTrendingWindow = "24h"
+	maxTrendingTags       = 20
+)
+
+// runTrendingAggregator recomputes the trending_tags table every interval so
+// that GET /api/trending only reads a handful of precomputed rows.
+func (cfg *apiConfig) runTrendingAggregator(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.refreshTrendingTags(context.Background()); err != nil {
+			log.Printf("Failed to refresh trending tags: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+func (cfg *apiConfig) refreshTrendingTags(ctx context.Context) error {
+	for window, length := range trendingWindows {
+		tx, err := cfg.dbConn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		q := cfg.db.WithTx(tx)
+
+		if err := q.DeleteTrendingTags(ctx, window); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = q.InsertTrendingTags(ctx, database.InsertTrendingTagsParams{
+			TimeWindow:    window,
+			WindowSeconds: int32(length / time.Second),
+			MaxTags:       maxTrendingTags,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) getTrendingHandler(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = defaultTrendingWindow
+	}
+	if _, ok := trendingWindows[window]; !ok {
+		respondWithError(w, http.StatusBadRequest, "window must be one of 1h, 24h or 7d")
+		return
+	}
+
+	rows, err := cfg.db.GetTrendingTags(r.Context(), window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve trending tags")
+		return
+	}
+
+	trending := Trending{
+		Window: window,
+		Tags:   make([]TrendingTag, len(rows)),
+	}
+	for i, row := range rows {
+		trending.Tags[i] = TrendingTag{
+			Tag:        row.Tag,
+			ChirpCount: row.ChirpCount,
+			Rank:       row.Rank,
+		}
+		trending.ComputedAt = &rows[i].ComputedAt
+	}
+
+	respondWithJson(w, http.StatusOK, trending)
+}
@@ -4,23 +4,37 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"sort"
-	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/VMT1312/Chirpy/internal/activitypub"
 	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/VMT1312/Chirpy/internal/blobstore"
+	"github.com/VMT1312/Chirpy/internal/chirptext"
 	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/VMT1312/Chirpy/internal/webhook"
 	"github.com/google/uuid"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	JWTSecret      string
 	polkaKey       string
+	broker         stream.Broker
+	blobs          blobstore.BlobStore
+	exportBlobs    blobstore.BlobStore
+	deletionGrace  time.Duration
+	baseURL        string
+	apClient       *activitypub.Client
+	adminKey       string
+	webhooks       *webhook.Sender
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -69,6 +83,11 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if params.Handle != "" && !chirptext.ValidHandle(params.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Handle must be 3-30 letters, digits or underscores")
+		return
+	}
+
 	hashed_password, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
@@ -78,74 +97,181 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request)
 	arg := database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashed_password,
+		Handle:         sql.NullString{String: params.Handle, Valid: params.Handle != ""},
 	}
 	dbUser, err := cfg.db.CreateUser(r.Context(), arg)
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Email or handle is already taken")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Failed to create user")
 		return
 	}
 
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.ChirpyRed,
-	}
-
-	respondWithJson(w, http.StatusCreated, user)
+	respondWithJson(w, http.StatusCreated, userFromDB(dbUser))
 }
 
 func (cfg *apiConfig) createChirpHandler(w http.ResponseWriter, r *http.Request) {
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	params := parameter{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	body, err := cleanChirpBody(params.Body)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	userID, err := auth.ValidateJWT(bearerToken, cfg.JWTSecret)
+	visibility, err := parseVisibility(params.Visibility)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
+	arg := database.CreateChirpParams{
+		Body:       body,
+		UserID:     userID,
+		Kind:       "original",
+		Visibility: visibility,
+	}
 
-	params := parameter{}
-	if err := decoder.Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if params.QuoteChirpID != uuid.Nil {
+		quoted, err := cfg.resolveOriginal(r, userID, params.QuoteChirpID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "Quoted chirp not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve quoted chirp")
+			return
+		}
+
+		arg.Kind = "quote"
+		arg.OriginalChirpID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	if params.ReplyToID != uuid.Nil {
+		parent, err := cfg.resolveOriginal(r, userID, params.ReplyToID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "Chirp being replied to not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp being replied to")
+			return
+		}
+
+		arg.ReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	if len(params.MediaIDs) > 0 {
+		if code, msg := cfg.validateChirpMedia(r, userID, params.MediaIDs); code != 0 {
+			respondWithError(w, code, msg)
+			return
+		}
+	}
+
+	var pollOptions []string
+	if params.Poll != nil {
+		pollOptions, err = validatePoll(params.Poll)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
 		return
 	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
 
-	if len(params.Body) <= 140 {
-		words := strings.Split(params.Body, " ")
-		for i, word := range words {
-			word = strings.ToLower(word)
-			if _, ok := bannedWords[word]; ok {
-				words[i] = "****"
+	dbChirp, err := q.CreateChirp(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
+		return
+	}
+
+	if len(params.MediaIDs) > 0 {
+		err = q.AttachChirpMedia(r.Context(), database.AttachChirpMediaParams{
+			ChirpID:  dbChirp.ID,
+			MediaIds: params.MediaIDs,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				respondWithError(w, http.StatusBadRequest, "Media not found or already attached")
+				return
 			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to attach media")
+			return
 		}
-	} else {
-		respondWithError(w, http.StatusBadRequest, "Body exceeds 140 characters")
 	}
 
-	arg := database.CreateChirpParams{
-		Body:   params.Body,
-		UserID: userID,
+	if params.Poll != nil {
+		err = q.CreatePoll(r.Context(), database.CreatePollParams{
+			ChirpID:  dbChirp.ID,
+			Multiple: params.Poll.Multiple,
+			ClosesAt: params.Poll.ClosesAt.UTC(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create poll")
+			return
+		}
+
+		err = q.CreatePollOptions(r.Context(), database.CreatePollOptionsParams{
+			ChirpID: dbChirp.ID,
+			Texts:   pollOptions,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create poll")
+			return
+		}
 	}
 
-	dbChirp, err := cfg.db.CreateChirp(r.Context(), arg)
+	mentioned, err := indexChirp(r.Context(), q, dbChirp)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
 		return
 	}
 
-	chirp := Chirp{
-		ID:        dbChirp.ID.String(),
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID.String(),
+	if err := recordWebhookEvent(r.Context(), q, userID, webhookChirpCreated, chirpFromDB(dbChirp)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
+		return
+	}
+
+	if err := cfg.federateChirp(r.Context(), q, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create chirp")
+		return
+	}
+
+	if err := cfg.announceChirp(r.Context(), dbChirp, mentioned); err != nil {
+		log.Printf("Failed to announce chirp %s: %v", dbChirp.ID, err)
+	}
+	if err := cfg.notifyCensored(r.Context(), dbChirp, params.Body); err != nil {
+		log.Printf("Failed to notify moderation of chirp %s: %v", dbChirp.ID, err)
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to load chirp")
+		return
 	}
 	respondWithJson(w, http.StatusCreated, chirp)
 }
@@ -153,76 +279,54 @@ func (cfg *apiConfig) createChirpHandler(w http.ResponseWriter, r *http.Request)
 func (cfg *apiConfig) getAllChirpsHandler(w http.ResponseWriter, r *http.Request) {
 	s := r.URL.Query().Get("author_id")
 	order := r.URL.Query().Get("sort")
+	viewerID := cfg.viewerID(r)
+
+	var dbChirps []database.Chirp
 	if s == "" {
-		dbChirps, err := cfg.db.GetAllChirps(r.Context())
+		chirps, err := cfg.db.GetAllChirps(r.Context(), viewerID)
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
 			return
 		}
-
-		chirps := make([]Chirp, len(dbChirps))
-		for i, dbChirp := range dbChirps {
-			chirps[i] = Chirp{
-				ID:        dbChirp.ID.String(),
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID.String(),
-			}
+		dbChirps = chirps
+	} else {
+		userID, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
+			return
 		}
 
-		if order == "asc" || order == "" {
-			sort.Slice(chirps, func(i, j int) bool {
-				return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-			})
-			respondWithJson(w, http.StatusOK, chirps)
-		} else {
-			sort.Slice(chirps, func(i, j int) bool {
-				return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-			})
-			respondWithJson(w, http.StatusOK, chirps)
+		chirps, err := cfg.db.GetChirpsByUserID(r.Context(), database.GetChirpsByUserIDParams{
+			UserID:   userID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+			return
 		}
-		return
+		dbChirps = chirps
 	}
 
-	userID, err := uuid.Parse(s)
+	chirps, err := cfg.buildChirps(r.Context(), viewerID, dbChirps)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid user ID format")
-		return
-	}
-
-	dbChirps, err := cfg.db.GetChirpsByUserID(r.Context(), userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "Chirp not found")
-			return
-		}
 		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
 		return
 	}
 
-	chirps := make([]Chirp, len(dbChirps))
-	for i, dbChirp := range dbChirps {
-		chirps[i] = Chirp{
-			ID:        dbChirp.ID.String(),
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID.String(),
-		}
-	}
-
 	if order == "asc" || order == "" {
 		sort.Slice(chirps, func(i, j int) bool {
 			return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
 		})
-		respondWithJson(w, http.StatusOK, chirps)
 	} else {
 		sort.Slice(chirps, func(i, j int) bool {
 			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
 		})
-		respondWithJson(w, http.StatusOK, chirps)
 	}
+	if s != "" {
+		pinnedFirst(chirps, dbChirps)
+	}
+
+	respondWithJson(w, http.StatusOK, chirps)
 }
 
 func (cfg *apiConfig) getChirpByIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -234,7 +338,11 @@ func (cfg *apiConfig) getChirpByIDHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	viewerID := cfg.viewerID(r)
+	dbChirp, err := cfg.db.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			respondWithError(w, http.StatusNotFound, "Chirp not found")
@@ -244,12 +352,10 @@ func (cfg *apiConfig) getChirpByIDHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	chirp := Chirp{
-		ID:        dbChirp.ID.String(),
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID.String(),
+	chirp, err := cfg.buildChirp(r.Context(), viewerID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		return
 	}
 
 	respondWithJson(w, http.StatusOK, chirp)
@@ -280,6 +386,19 @@ func (cfg *apiConfig) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Logging in during the grace period cancels a pending deletion.
+	if dbUser.DeleteAfter.Valid {
+		cancelled, err := cfg.db.CancelUserDeletion(r.Context(), dbUser.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to cancel account deletion")
+			return
+		}
+		if cancelled == 0 {
+			respondWithError(w, http.StatusUnauthorized, "Account has been deleted")
+			return
+		}
+	}
+
 	token, err := auth.MakeJWT(dbUser.ID, cfg.JWTSecret, time.Hour)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create JWT token")
@@ -303,15 +422,9 @@ func (cfg *apiConfig) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := User{
-		ID:           dbUser.ID,
-		CreatedAt:    dbUser.CreatedAt,
-		UpdatedAt:    dbUser.UpdatedAt,
-		Email:        dbUser.Email,
-		Token:        token,
-		RefreshToken: refreshTokenDB.Token,
-		IsChirpyRed:  dbUser.ChirpyRed,
-	}
+	user := userFromDB(dbUser)
+	user.Token = token
+	user.RefreshToken = refreshTokenDB.Token
 
 	respondWithJson(w, http.StatusOK, user)
 }
@@ -375,15 +488,8 @@ func (cfg *apiConfig) revokeTokenHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (cfg *apiConfig) updateUserHandler(w http.ResponseWriter, r *http.Request) {
-	accessToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
-		return
-	}
-
-	userID, err := auth.ValidateJWT(accessToken, cfg.JWTSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -394,31 +500,62 @@ func (cfg *apiConfig) updateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	if err := validateProfile(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
 		return
 	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
 
-	arg := database.UpdatePasswordByIDParams{
-		HashedPassword: hashedPassword,
-		ID:             userID,
-		Email:          params.Email,
+	if params.Password != "" {
+		hashedPassword, err := auth.HashPassword(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
+			return
+		}
+
+		err = q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update password")
+			return
+		}
+	}
+
+	arg := database.UpdateUserProfileParams{
+		Email:                sql.NullString{String: params.Email, Valid: params.Email != ""},
+		Handle:               sql.NullString{String: params.Handle, Valid: params.Handle != ""},
+		DisplayName:          optionalString(params.DisplayName),
+		Bio:                  optionalString(params.Bio),
+		AvatarUrl:            optionalString(params.AvatarURL),
+		Location:             optionalString(params.Location),
+		DmsFromFollowersOnly: optionalBool(params.DMsFromFollowersOnly),
+		ID:                   userID,
 	}
-	dbUser, err := cfg.db.UpdatePasswordByID(r.Context(), arg)
+	dbUser, err := q.UpdateUserProfile(r.Context(), arg)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to update password")
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Email or handle is already taken")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
 		return
 	}
 
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.ChirpyRed,
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
+		return
 	}
-	respondWithJson(w, http.StatusOK, user)
+
+	respondWithJson(w, http.StatusOK, userFromDB(dbUser))
 }
 
 func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request) {
@@ -428,15 +565,8 @@ func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
-		return
-	}
-
-	userID, err := auth.ValidateJWT(bearerToken, cfg.JWTSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -456,15 +586,57 @@ func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if dbChirp.UserID != userID {
+		visible, err := cfg.canSeeDBChirp(r.Context(), userID, dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
 		respondWithError(w, http.StatusForbidden, "You do not have permission to delete this chirp")
 		return
 	}
 
-	err = cfg.db.DeleteChirpByID(r.Context(), chirpUUID)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp")
 		return
 	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	trashed, err := q.TrashChirp(r.Context(), chirpUUID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp")
+		return
+	}
+	if dbChirp.Status == "published" {
+		err = recordWebhookEvent(r.Context(), q, userID, webhookChirpDeleted, map[string]string{
+			"chirp_id": dbChirp.ID.String(),
+			"user_id":  dbChirp.UserID.String(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp")
+			return
+		}
+	}
+	if err := cfg.federateChirpDeletion(r.Context(), q, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp")
+		return
+	}
+	// Rechirps of the chirp are trashed with it and disappear from feeds too.
+	for _, c := range trashed {
+		if c.Status == "published" {
+			cfg.publishStreamEvent(r.Context(), stream.ChirpDeleted, c)
+		}
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -494,15 +666,52 @@ func (cfg *apiConfig) upgradeUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = cfg.db.UpgradeUserByID(r.Context(), params.Data.UserID)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "Chirp not found")
+		respondWithError(w, http.StatusInternalServerError, "Failed to upgrade user")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	upgraded, err := q.UpgradeUserByID(r.Context(), params.Data.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to upgrade user")
+		return
+	}
+
+	// Polka may deliver the same event more than once. A user who is
+	// already upgraded is acknowledged without a second event or
+	// notification.
+	if upgraded == 0 {
+		if _, err := q.GetUserByID(r.Context(), params.Data.UserID); err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = recordWebhookEvent(r.Context(), q, params.Data.UserID, webhookUserUpgraded, map[string]string{
+		"user_id": params.Data.UserID.String(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to upgrade user")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to upgrade user")
 		return
 	}
 
+	if err := cfg.notify(r.Context(), params.Data.UserID, notificationChirpyRed, uuid.Nil, uuid.Nil); err != nil {
+		log.Printf("Failed to notify Chirpy Red upgrade of user %s: %v", params.Data.UserID, err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
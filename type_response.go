@@ -1,29 +1,256 @@
 package main
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/VMT1312/Chirpy/internal/importer"
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	ID                   uuid.UUID `json:"id"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+	Email                string    `json:"email"`
+	Handle               string    `json:"handle,omitempty"`
+	DisplayName          string    `json:"display_name"`
+	Bio                  string    `json:"bio"`
+	AvatarURL            string    `json:"avatar_url"`
+	Location             string    `json:"location"`
+	Token                string    `json:"token,omitempty"`
+	RefreshToken         string    `json:"refresh_token,omitempty"`
+	IsChirpyRed          bool      `json:"is_chirpy_red"`
+	DMsFromFollowersOnly bool      `json:"dms_from_followers_only"`
+}
+
+type AccountDeletion struct {
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+type ImportReport struct {
+	Imported int                  `json:"imported"`
+	Skipped  int                  `json:"skipped"`
+	Censored int                  `json:"censored"`
+	Failed   int                  `json:"failed"`
+	Errors   []importer.LineError `json:"errors"`
+}
+
+type Export struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	Location       string    `json:"location"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	PinnedChirps   []Chirp   `json:"pinned_chirps"`
 }
 
 type Chirp struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    string    `json:"user_id"`
+	ID                  string          `json:"id"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+	Body                string          `json:"body"`
+	UserID              string          `json:"user_id"`
+	Kind                string          `json:"kind"`
+	Pinned              bool            `json:"pinned,omitempty"`
+	Visibility          string          `json:"visibility"`
+	OriginalChirpID     *string         `json:"original_chirp_id,omitempty"`
+	ReplyToID           *string         `json:"reply_to_id,omitempty"`
+	Original            *Chirp          `json:"original,omitempty"`
+	OriginalUnavailable bool            `json:"original_unavailable,omitempty"`
+	Mentions            []MentionEntity `json:"mentions"`
+	Media               []Media         `json:"media"`
+	Poll                *Poll           `json:"poll,omitempty"`
+	LikeCount           int32           `json:"like_count"`
+	LikedByMe           *bool           `json:"liked_by_me,omitempty"`
+	BookmarkedByMe      *bool           `json:"bookmarked_by_me,omitempty"`
+	Status              string          `json:"status,omitempty"`
+	PublishAt           *time.Time      `json:"publish_at,omitempty"`
+	DeletedAt           *time.Time      `json:"deleted_at,omitempty"`
+}
+
+type Media struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+}
+
+type Poll struct {
+	Options    []PollOption `json:"options"`
+	Multiple   bool         `json:"multiple"`
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	VoterCount *int64       `json:"voter_count,omitempty"`
+	Voted      bool         `json:"voted"`
+	OwnVotes   []int32      `json:"own_votes,omitempty"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type MentionEntity struct {
+	UserID string `json:"user_id"`
+	Handle string `json:"handle"`
+	Start  int32  `json:"start"`
+	End    int32  `json:"end"`
+}
+
+type Rechirp struct {
+	UserID      string    `json:"user_id"`
+	RechirpedAt time.Time `json:"rechirped_at"`
 }
 
 type AccessToken struct {
 	Token string `json:"token"`
 }
+
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type Follow struct {
+	UserID     string    `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowList struct {
+	Count int      `json:"count"`
+	Users []Follow `json:"users"`
+}
+
+type List struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	MemberCount int64     `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UserRelation struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ChirpRevision struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TrendingTag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+	Rank       int32  `json:"rank"`
+}
+
+type Trending struct {
+	Window     string        `json:"window"`
+	ComputedAt *time.Time    `json:"computed_at,omitempty"`
+	Tags       []TrendingTag `json:"tags"`
+}
+
+type Notification struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Type        string    `json:"type"`
+	ActorID     *string   `json:"actor_id,omitempty"`
+	ActorHandle string    `json:"actor_handle,omitempty"`
+	ChirpID     *string   `json:"chirp_id,omitempty"`
+	Read        bool      `json:"read"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type UnreadCount struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type NotificationPreferences struct {
+	Preferences map[string]bool `json:"preferences"`
+}
+
+type Conversation struct {
+	ID          string               `json:"id"`
+	Members     []ConversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type ConversationMember struct {
+	UserID     string     `json:"user_id"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	SenderID       *string   `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type WSMessage struct {
+	Type      string          `json:"type"`
+	Channel   string          `json:"channel,omitempty"`
+	ChirpID   string          `json:"chirp_id,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Message   string          `json:"message,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+type WebhookEndpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int32           `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	LastStatusCode *int32          `json:"last_status_code,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
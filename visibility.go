@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityPrivate   = "private"
+)
+
+// parseVisibility validates the visibility requested for a new chirp,
+// defaulting to public.
+func parseVisibility(s string) (string, error) {
+	switch s {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityUnlisted, visibilityFollowers, visibilityPrivate:
+		return s, nil
+	default:
+		return "", errors.New("visibility must be one of public, unlisted, followers or private")
+	}
+}
+
+// canSeeChirp reports whether viewerID may read a chirp by authorID with the
+// given visibility. Blocks are checked separately by the queries that load
+// chirps. Pass uuid.Nil for anonymous viewers.
+func (cfg *apiConfig) canSeeChirp(ctx context.Context, viewerID, authorID uuid.UUID, visibility string) (bool, error) {
+	if viewerID != uuid.Nil && viewerID == authorID {
+		return true, nil
+	}
+
+	switch visibility {
+	case visibilityPublic, visibilityUnlisted:
+		return true, nil
+	case visibilityFollowers:
+		if viewerID == uuid.Nil {
+			return false, nil
+		}
+		return cfg.db.IsFollowing(ctx, database.IsFollowingParams{
+			FollowerID: viewerID,
+			FolloweeID: authorID,
+		})
+	default:
+		return false, nil
+	}
+}
+
+// canSeeDBChirp reports whether viewerID can see a loaded chirp. Authors see
+// all of their own chirps, drafts included. Anyone else is checked with
+// GetChirpForViewer, so a block hides the chirp as if it did not exist.
+func (cfg *apiConfig) canSeeDBChirp(ctx context.Context, viewerID uuid.UUID, dbChirp database.Chirp) (bool, error) {
+	if viewerID != uuid.Nil && dbChirp.UserID == viewerID {
+		return true, nil
+	}
+
+	_, err := cfg.db.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+		ID:       dbChirp.ID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookChirpCreated = "chirp.created"
+	webhookChirpDeleted = "chirp.deleted"
+	webhookUserUpgraded = "user.upgraded"
+	webhookTest         = "webhook.test"
+
+	maxWebhookEndpoints      = 10
+	webhookEventBatchSize    = 100
+	webhookDeliveryBatchSize = 20
+	webhookDeliveryLease     = 5 * time.Minute
+	webhookMaxAttempts       = 8
+	webhookBaseDelay         = 30 * time.Second
+	webhookMaxDelay          = 6 * time.Hour
+	webhookEventRetention    = 24 * time.Hour
+)
+
+// webhookEventTypes are the events endpoints can subscribe to. Test events
+// are sent on request to a single endpoint and cannot be subscribed to.
+var webhookEventTypes = []string{
+	webhookChirpCreated,
+	webhookChirpDeleted,
+	webhookUserUpgraded,
+}
+
+// validateWebhookEndpoint checks a new endpoint. Endpoints owned by users
+// must use https; only admins may register plain http URLs.
+func validateWebhookEndpoint(rawURL string, events []string, owner uuid.NullUUID) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if owner.Valid && u.Scheme != "https" {
+		return errors.New("url must be an https URL")
+	}
+
+	if len(events) == 0 {
+		return errors.New("events must not be empty")
+	}
+	for _, event := range events {
+		known := false
+		for _, t := range webhookEventTypes {
+			if event == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return errors.New("events must be one of " + strings.Join(webhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+func webhookEndpointFromDB(dbEndpoint database.WebhookEndpoint) WebhookEndpoint {
+	return WebhookEndpoint{
+		ID:        dbEndpoint.ID.String(),
+		URL:       dbEndpoint.Url,
+		Events:    dbEndpoint.Events,
+		CreatedAt: dbEndpoint.CreatedAt,
+	}
+}
+
+// newWebhookEvent builds the body sent for an event.
+func newWebhookEvent(eventType string, data interface{}) (WebhookEvent, []byte, error) {
+	event := WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return WebhookEvent{}, nil, err
+	}
+	return event, payload, nil
+}
+
+// recordWebhookEvent writes an event about userID to the outbox. q should be
+// bound to the transaction making the change the event describes.
+func recordWebhookEvent(ctx context.Context, q *database.Queries, userID uuid.UUID, eventType string, data interface{}) error {
+	event, payload, err := newWebhookEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateWebhookEvent(ctx, database.CreateWebhookEventParams{
+		ID:      uuid.MustParse(event.ID),
+		UserID:  uuid.NullUUID{UUID: userID, Valid: true},
+		Type:    eventType,
+		Payload: string(payload),
+	})
+}
+
+// webhookOwner authenticates a webhook management request. Routes under
+// /admin/ take the admin API key and manage endpoints that receive events
+// about every user; the others manage the caller's own endpoints.
+func (cfg *apiConfig) webhookOwner(w http.ResponseWriter, r *http.Request) (uuid.NullUUID, bool) {
+	if strings.HasPrefix(r.URL.Path, "/admin/") {
+		apiKey, err := auth.GetAPIKey(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
+			return uuid.NullUUID{}, false
+		}
+		if cfg.adminKey == "" || apiKey != cfg.adminKey {
+			respondWithError(w, http.StatusForbidden, "Invalid API key")
+			return uuid.NullUUID{}, false
+		}
+		return uuid.NullUUID{}, true
+	}
+
+	userID, ok := cfg.authenticatedUserID(w, r)
+	if !ok {
+		return uuid.NullUUID{}, false
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, true
+}
+
+// ownedWebhookEndpoint loads the endpoint named in the path if it belongs to
+// owner, writing an error response otherwise.
+func (cfg *apiConfig) ownedWebhookEndpoint(w http.ResponseWriter, r *http.Request, owner uuid.NullUUID) (database.WebhookEndpoint, bool) {
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID format")
+		return database.WebhookEndpoint{}, false
+	}
+
+	dbEndpoint, err := cfg.db.GetWebhookEndpoint(r.Context(), database.GetWebhookEndpointParams{
+		ID:     webhookID,
+		UserID: owner,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Webhook not found")
+			return database.WebhookEndpoint{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhook")
+		return database.WebhookEndpoint{}, false
+	}
+	return dbEndpoint, true
+}
+
+func (cfg *apiConfig) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	params := parameter{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := validateWebhookEndpoint(params.URL, params.Events, owner); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := cfg.db.GetWebhookEndpoints(r.Context(), owner)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhooks")
+		return
+	}
+	if len(existing) >= maxWebhookEndpoints {
+		respondWithError(w, http.StatusConflict, "Webhook limit reached")
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	dbEndpoint, err := cfg.db.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID: owner,
+		Url:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	// The secret is only ever shown here.
+	endpoint := webhookEndpointFromDB(dbEndpoint)
+	endpoint.Secret = dbEndpoint.Secret
+	respondWithJson(w, http.StatusCreated, endpoint)
+}
+
+func (cfg *apiConfig) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	dbEndpoints, err := cfg.db.GetWebhookEndpoints(r.Context(), owner)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve webhooks")
+		return
+	}
+
+	endpoints := make([]WebhookEndpoint, 0, len(dbEndpoints))
+	for _, dbEndpoint := range dbEndpoints {
+		endpoints = append(endpoints, webhookEndpointFromDB(dbEndpoint))
+	}
+
+	respondWithJson(w, http.StatusOK, endpoints)
+}
+
+func (cfg *apiConfig) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID format")
+		return
+	}
+
+	n, err := cfg.db.DeleteWebhookEndpoint(r.Context(), database.DeleteWebhookEndpointParams{
+		ID:     webhookID,
+		UserID: owner,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) testWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r, owner)
+	if !ok {
+		return
+	}
+
+	event, payload, err := newWebhookEvent(webhookTest, map[string]string{
+		"webhook_id": dbEndpoint.ID.String(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send test event")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send test event")
+		return
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	// The event is marked dispatched so the worker does not fan it out to
+	// other endpoints.
+	eventID := uuid.MustParse(event.ID)
+	err = q.CreateWebhookEvent(r.Context(), database.CreateWebhookEventParams{
+		ID:           eventID,
+		UserID:       owner,
+		Type:         webhookTest,
+		Payload:      string(payload),
+		DispatchedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send test event")
+		return
+	}
+
+	err = q.CreateWebhookDelivery(r.Context(), database.CreateWebhookDeliveryParams{
+		EndpointID: dbEndpoint.ID,
+		EventID:    eventID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send test event")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send test event")
+		return
+	}
+
+	respondWithJson(w, http.StatusAccepted, event)
+}
+
+func (cfg *apiConfig) getDeadWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r, owner)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetDeadWebhookDeliveries(r.Context(), dbEndpoint.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve deliveries")
+		return
+	}
+
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		delivery := WebhookDelivery{
+			ID:        row.ID.String(),
+			EventID:   row.EventID.String(),
+			Event:     row.Type,
+			Payload:   json.RawMessage(row.Payload),
+			Attempts:  row.Attempts,
+			LastError: row.LastError.String,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.LastStatusCode.Valid {
+			delivery.LastStatusCode = &row.LastStatusCode.Int32
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	respondWithJson(w, http.StatusOK, deliveries)
+}
+
+func (cfg *apiConfig) retryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r, owner)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery ID format")
+		return
+	}
+
+	n, err := cfg.db.RequeueDeadWebhookDelivery(r.Context(), database.RequeueDeadWebhookDeliveryParams{
+		ID:         deliveryID,
+		EndpointID: dbEndpoint.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retry delivery")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Dead delivery not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// runWebhookDispatcher fans new outbox events out to the endpoints
+// subscribed to them and sends due deliveries.
+func (cfg *apiConfig) runWebhookDispatcher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.dispatchWebhookEvents(context.Background())
+			if err != nil {
+				log.Printf("Failed to dispatch webhook events: %v", err)
+				break
+			}
+			if n < webhookEventBatchSize {
+				break
+			}
+		}
+		for {
+			n, err := cfg.deliverWebhooks(context.Background())
+			if err != nil {
+				log.Printf("Failed to deliver webhooks: %v", err)
+				break
+			}
+			if n < webhookDeliveryBatchSize {
+				break
+			}
+		}
+		err := cfg.db.DeleteFinishedWebhookEvents(context.Background(), int32(webhookEventRetention.Seconds()))
+		if err != nil {
+			log.Printf("Failed to delete finished webhook events: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// dispatchWebhookEvents creates the deliveries for one batch of outbox
+// events. The events stay locked until the deliveries are committed, so each
+// is fanned out exactly once.
+func (cfg *apiConfig) dispatchWebhookEvents(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := cfg.db.WithTx(tx)
+
+	events, err := q.LockUndispatchedWebhookEvents(ctx, webhookEventBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		err := q.CreateWebhookDeliveriesForEvent(ctx, database.CreateWebhookDeliveriesForEventParams{
+			EventID: event.ID,
+			UserID:  event.UserID,
+			Type:    event.Type,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := q.MarkWebhookEventDispatched(ctx, event.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// deliverWebhooks claims one batch of due deliveries and sends them.
+// Claiming leases each row for webhookDeliveryLease, so a delivery is retried
+// even if this process dies before recording the outcome.
+func (cfg *apiConfig) deliverWebhooks(ctx context.Context) (int, error) {
+	deliveries, err := cfg.db.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseSeconds:  int32(webhookDeliveryLease.Seconds()),
+		MaxDeliveries: webhookDeliveryBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, d := range deliveries {
+		err := cfg.webhooks.Send(ctx, webhook.Request{
+			URL:        d.Url,
+			Secret:     d.Secret,
+			DeliveryID: d.ID.String(),
+			Event:      d.Type,
+			Payload:    []byte(d.Payload),
+		})
+		if err := cfg.recordWebhookDelivery(ctx, d, err); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// recordWebhookDelivery removes a delivered request, or schedules a retry
+// with exponential backoff. Deliveries that run out of attempts become dead
+// letters.
+func (cfg *apiConfig) recordWebhookDelivery(ctx context.Context, d database.ClaimDueWebhookDeliveriesRow, sendErr error) error {
+	if sendErr == nil {
+		return cfg.db.CompleteWebhookDelivery(ctx, d.ID)
+	}
+
+	lastError := sql.NullString{String: sendErr.Error(), Valid: true}
+	var statusCode sql.NullInt32
+	var statusErr *webhook.StatusError
+	if errors.As(sendErr, &statusErr) {
+		statusCode = sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+	}
+
+	if d.Attempts >= webhookMaxAttempts {
+		return cfg.db.KillWebhookDelivery(ctx, database.KillWebhookDeliveryParams{
+			LastError:      lastError,
+			LastStatusCode: statusCode,
+			ID:             d.ID,
+		})
+	}
+
+	delay := webhookBaseDelay << (d.Attempts - 1)
+	if delay > webhookMaxDelay {
+		delay = webhookMaxDelay
+	}
+	return cfg.db.RetryWebhookDelivery(ctx, database.RetryWebhookDeliveryParams{
+		DelaySeconds:   int32(delay.Seconds()),
+		LastError:      lastError,
+		LastStatusCode: statusCode,
+		ID:             d.ID,
+	})
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/VMT1312/Chirpy/internal/auth"
+	"github.com/VMT1312/Chirpy/internal/database"
+	"github.com/VMT1312/Chirpy/internal/stream"
+	"github.com/VMT1312/Chirpy/internal/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	wsPingInterval   = 30 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsWriteTimeout   = 10 * time.Second
+	wsReauthGrace    = 30 * time.Second
+	wsSendBufferSize = 64
+	wsMaxMessageSize = 4096
+)
+
+const (
+	wsChannelTimeline      = "timeline"
+	wsChannelNotifications = "notifications"
+	wsChannelMessages      = "messages"
+	wsChannelChirp         = "chirp"
+)
+
+// wsRequest is a message from the client.
+type wsRequest struct {
+	Type    string    `json:"type"`
+	Channel string    `json:"channel"`
+	ChirpID uuid.UUID `json:"chirp_id"`
+	Token   string    `json:"token"`
+}
+
+// wsClient is one WebSocket connection. Its read loop handles client
+// messages, its write loop is the only writer of data frames, and the
+// handler goroutine dispatches broker events and tracks token expiry.
+type wsClient struct {
+	cfg  *apiConfig
+	conn *websocket.Conn
+	send chan WSMessage
+	done chan struct{}
+	once sync.Once
+
+	mu         sync.Mutex
+	userID     uuid.UUID
+	expiresAt  time.Time
+	reauthSent bool
+	timeline   bool
+	following  map[uuid.UUID]bool
+	notifyOn   bool
+	messagesOn bool
+	threads    map[uuid.UUID]bool
+	mutedUsers map[uuid.UUID]bool
+}
+
+func (cfg *apiConfig) websocketHandler(w http.ResponseWriter, r *http.Request) {
+	// Browsers cannot set headers on a WebSocket request, so the token may
+	// also be passed as a query parameter.
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil || token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized: missing access token")
+		return
+	}
+
+	userID, expiresAt, err := auth.ValidateJWTWithExpiry(token, cfg.JWTSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+		return
+	}
+
+	active, err := cfg.db.IsActiveUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+	if !active {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token: account is deleted or pending deletion")
+		return
+	}
+
+	dbMutes, err := cfg.db.GetMutedUsers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve mutes")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) {
+			respondWithError(w, http.StatusBadRequest, "Expected a WebSocket handshake")
+			return
+		}
+		log.Printf("Failed to upgrade WebSocket connection: %v", err)
+		return
+	}
+
+	c := &wsClient{
+		cfg:        cfg,
+		conn:       conn,
+		send:       make(chan WSMessage, wsSendBufferSize),
+		done:       make(chan struct{}),
+		userID:     userID,
+		expiresAt:  expiresAt,
+		threads:    map[uuid.UUID]bool{},
+		mutedUsers: map[uuid.UUID]bool{},
+	}
+	for _, m := range dbMutes {
+		c.mutedUsers[m.MutedID] = true
+	}
+
+	sub := cfg.broker.Subscribe(0)
+	defer sub.Close()
+
+	go c.readLoop()
+	go c.writeLoop()
+	c.run(sub)
+}
+
+// close ends the connection with the given status. Only the first call has
+// any effect.
+func (c *wsClient) close(code int, reason string) {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close(code, reason)
+	})
+}
+
+// enqueue queues msg for the write loop. A client that lets its queue fill up
+// is disconnected rather than allowed to hold events in memory.
+func (c *wsClient) enqueue(msg WSMessage) {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+		c.close(websocket.CloseTryAgainLater, "slow consumer")
+	}
+}
+
+func (c *wsClient) run(sub *stream.Subscription) {
+	c.mu.Lock()
+	reauth := time.NewTimer(time.Until(c.expiresAt))
+	c.mu.Unlock()
+	defer reauth.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), stream.ErrSlowConsumer) {
+					c.close(websocket.CloseTryAgainLater, "slow consumer")
+				} else {
+					c.close(websocket.CloseGoingAway, "server shutting down")
+				}
+				return
+			}
+			c.dispatch(e)
+
+		case <-reauth.C:
+			c.mu.Lock()
+			expiresAt := c.expiresAt
+			reauthSent := c.reauthSent
+			c.mu.Unlock()
+
+			switch {
+			case time.Now().Before(expiresAt):
+				// The client re-authenticated since the timer was set.
+				reauth.Reset(time.Until(expiresAt))
+			case !reauthSent:
+				c.mu.Lock()
+				c.reauthSent = true
+				c.mu.Unlock()
+				c.enqueue(WSMessage{Type: "reauth_required", ExpiresAt: &expiresAt})
+				reauth.Reset(wsReauthGrace)
+			default:
+				c.close(websocket.ClosePolicyViolation, "token expired")
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) readLoop() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.OnPong(func() {
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.close(websocket.CloseNormalClosure, "")
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		if messageType != websocket.TextMessage {
+			c.enqueue(WSMessage{Type: "error", Message: "Messages must be JSON text"})
+			continue
+		}
+
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.enqueue(WSMessage{Type: "error", Message: "Invalid message"})
+			continue
+		}
+		c.handle(req)
+	}
+}
+
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case msg := <-c.send:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				log.Printf("Failed to encode WebSocket message: %v", err)
+				continue
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close(websocket.CloseGoingAway, "")
+				return
+			}
+
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				c.close(websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) handle(req wsRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+
+	switch req.Type {
+	case "subscribe":
+		if err := c.subscribe(ctx, req); err != nil {
+			c.enqueue(WSMessage{Type: "error", Channel: req.Channel, Message: err.Error()})
+			return
+		}
+		c.enqueue(WSMessage{Type: "subscribed", Channel: req.Channel, ChirpID: chirpIDString(req)})
+
+	case "unsubscribe":
+		c.mu.Lock()
+		switch req.Channel {
+		case wsChannelTimeline:
+			c.timeline = false
+			c.following = nil
+		case wsChannelNotifications:
+			c.notifyOn = false
+		case wsChannelMessages:
+			c.messagesOn = false
+		case wsChannelChirp:
+			delete(c.threads, req.ChirpID)
+		}
+		c.mu.Unlock()
+		c.enqueue(WSMessage{Type: "unsubscribed", Channel: req.Channel, ChirpID: chirpIDString(req)})
+
+	case "auth":
+		userID, expiresAt, err := auth.ValidateJWTWithExpiry(req.Token, c.cfg.JWTSecret)
+		if err != nil {
+			c.enqueue(WSMessage{Type: "error", Message: "Invalid token: " + err.Error()})
+			return
+		}
+
+		c.mu.Lock()
+		sameUser := userID == c.userID
+		if sameUser {
+			c.expiresAt = expiresAt
+			c.reauthSent = false
+		}
+		c.mu.Unlock()
+
+		if !sameUser {
+			c.close(websocket.ClosePolicyViolation, "token belongs to another user")
+			return
+		}
+		if active, err := c.cfg.db.IsActiveUser(ctx, userID); err != nil || !active {
+			c.close(websocket.ClosePolicyViolation, "account is deleted or pending deletion")
+			return
+		}
+		c.enqueue(WSMessage{Type: "authenticated", ExpiresAt: &expiresAt})
+
+	case "ping":
+		c.enqueue(WSMessage{Type: "pong"})
+
+	default:
+		c.enqueue(WSMessage{Type: "error", Message: "Unknown message type"})
+	}
+}
+
+func chirpIDString(req wsRequest) string {
+	if req.Channel != wsChannelChirp {
+		return ""
+	}
+	return req.ChirpID.String()
+}
+
+// subscribe adds a channel. The timeline channel uses the caller's follows at
+// the time of subscribing.
+func (c *wsClient) subscribe(ctx context.Context, req wsRequest) error {
+	switch req.Channel {
+	case wsChannelTimeline:
+		following, err := c.cfg.db.GetFollowing(ctx, c.userID)
+		if err != nil {
+			return errors.New("Failed to retrieve follows")
+		}
+
+		authors := map[uuid.UUID]bool{c.userID: true}
+		for _, f := range following {
+			authors[f.FolloweeID] = true
+		}
+
+		c.mu.Lock()
+		c.timeline = true
+		c.following = authors
+		c.mu.Unlock()
+		return nil
+
+	case wsChannelNotifications:
+		c.mu.Lock()
+		c.notifyOn = true
+		c.mu.Unlock()
+		return nil
+
+	case wsChannelMessages:
+		c.mu.Lock()
+		c.messagesOn = true
+		c.mu.Unlock()
+		return nil
+
+	case wsChannelChirp:
+		_, err := c.cfg.db.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+			ID:       req.ChirpID,
+			ViewerID: c.userID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.New("Chirp not found")
+			}
+			return errors.New("Failed to retrieve chirp")
+		}
+
+		c.mu.Lock()
+		c.threads[req.ChirpID] = true
+		c.mu.Unlock()
+		return nil
+
+	default:
+		return errors.New("channel must be one of timeline, notifications, messages or chirp")
+	}
+}
+
+func (c *wsClient) dispatch(e stream.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+
+	c.mu.Lock()
+	userID := c.userID
+	notifyOn := c.notifyOn
+	messagesOn := c.messagesOn
+	var channels []WSMessage
+	if e.IsChirpEvent() && !c.mutedUsers[e.AuthorID] {
+		if c.timeline && c.following[e.AuthorID] {
+			channels = append(channels, WSMessage{Channel: wsChannelTimeline})
+		}
+		for _, threadID := range []uuid.UUID{e.ChirpID, e.ReplyToID.UUID} {
+			if threadID != uuid.Nil && c.threads[threadID] {
+				channels = append(channels, WSMessage{Channel: wsChannelChirp, ChirpID: threadID.String()})
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if e.Type == stream.NotificationCreated {
+		if !notifyOn || e.RecipientID != userID {
+			return
+		}
+
+		n, err := c.cfg.db.GetNotificationForUser(ctx, database.GetNotificationForUserParams{
+			ID:     e.NotificationID,
+			UserID: userID,
+		})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("Failed to load notification %s: %v", e.NotificationID, err)
+			}
+			return
+		}
+
+		data, err := json.Marshal(notificationFromDB(database.GetNotificationsRow(n)))
+		if err != nil {
+			log.Printf("Failed to encode notification %s: %v", e.NotificationID, err)
+			return
+		}
+		c.enqueue(WSMessage{Type: "event", Channel: wsChannelNotifications, Event: e.Type, Data: data})
+		return
+	}
+
+	if e.Type == stream.MessageCreated {
+		if !messagesOn || e.RecipientID != userID {
+			return
+		}
+
+		m, err := c.cfg.db.GetMessageForMember(ctx, database.GetMessageForMemberParams{
+			ID:     e.MessageID,
+			UserID: userID,
+		})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("Failed to load message %s: %v", e.MessageID, err)
+			}
+			return
+		}
+
+		data, err := json.Marshal(messageFromDB(m))
+		if err != nil {
+			log.Printf("Failed to encode message %s: %v", e.MessageID, err)
+			return
+		}
+		c.enqueue(WSMessage{Type: "event", Channel: wsChannelMessages, Event: e.Type, Data: data})
+		return
+	}
+
+	if len(channels) == 0 {
+		return
+	}
+
+	data, visible, err := c.cfg.streamEventData(ctx, userID, e)
+	if err != nil {
+		log.Printf("Failed to render %s event for chirp %s: %v", e.Type, e.ChirpID, err)
+		return
+	}
+	if !visible {
+		return
+	}
+
+	for _, msg := range channels {
+		msg.Type = "event"
+		msg.Event = e.Type
+		msg.Data = data
+		c.enqueue(msg)
+	}
+}